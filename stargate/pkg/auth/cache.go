@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TokenInvalidator is implemented by providers that cache their token. When
+// the gateway rejects a token, InvalidateToken discards it so that the next
+// request fetches a fresh one. The token is a metadata value previously
+// returned by GetRequestMetadata, and values the provider did not issue, or
+// has already replaced, are ignored.
+type TokenInvalidator interface {
+	InvalidateToken(token string)
+}
+
+// tokenCache holds a token fetched by fetch until it expires. Concurrent
+// callers share a single fetch, and once a token enters the last part of its
+// lifetime it is refreshed in the background while the current token
+// continues to be used.
+type tokenCache struct {
+	fetch func(ctx context.Context) (string, error)
+	// lifetime returns when a token fetched at fetchedAt should be refreshed
+	// in the background and when it expires. Zero times mean never.
+	lifetime func(token string, fetchedAt time.Time) (refreshAt, expiresAt time.Time)
+	now      func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+	expiresAt time.Time
+	inflight  *tokenFetch
+}
+
+type tokenFetch struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// refreshFraction is the fraction of a token's lifetime after which it is
+// refreshed in the background.
+const refreshFraction = 0.8
+
+func newTokenCache(ttl time.Duration, fetch func(ctx context.Context) (string, error)) *tokenCache {
+	return &tokenCache{
+		fetch: fetch,
+		lifetime: func(_ string, fetchedAt time.Time) (time.Time, time.Time) {
+			return fetchedAt.Add(time.Duration(float64(ttl) * refreshFraction)), fetchedAt.Add(ttl)
+		},
+		now: time.Now,
+	}
+}
+
+func (c *tokenCache) get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	now := c.now()
+	if c.token != "" && (c.expiresAt.IsZero() || now.Before(c.expiresAt)) {
+		token := c.token
+		if !c.refreshAt.IsZero() && !now.Before(c.refreshAt) && c.inflight == nil {
+			c.start()
+		}
+		c.mu.Unlock()
+		return token, nil
+	}
+
+	f := c.inflight
+	if f == nil {
+		f = c.start()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.token, f.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// start begins fetching a token and must be called with mu held. The fetch is
+// not tied to any caller's context, since other callers may be waiting on it.
+func (c *tokenCache) start() *tokenFetch {
+	f := &tokenFetch{done: make(chan struct{})}
+	c.inflight = f
+
+	go func() {
+		fetchedAt := c.now()
+		f.token, f.err = c.fetch(context.Background())
+
+		c.mu.Lock()
+		c.inflight = nil
+		if f.err == nil {
+			c.token = f.token
+			c.refreshAt, c.expiresAt = c.lifetime(f.token, fetchedAt)
+		} else if c.token != "" {
+			log.WithError(f.err).Warn("Failed to refresh auth token, continuing to use the current one")
+		}
+		c.mu.Unlock()
+
+		close(f.done)
+	}()
+
+	return f
+}
+
+// invalidate discards token if it is still the cached one, leaving a token
+// that has already been refreshed in place.
+func (c *tokenCache) invalidate(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == token {
+		c.token = ""
+	}
+}
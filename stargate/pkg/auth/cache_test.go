@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for tokenCache.now.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// countingFetch returns token-1, token-2, ... and counts how often it is
+// called.
+func countingFetch(calls *int32) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		return fmt.Sprintf("token-%d", atomic.AddInt32(calls, 1)), nil
+	}
+}
+
+func newTestCache(ttl time.Duration, fetch func(ctx context.Context) (string, error)) (*tokenCache, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	c := newTokenCache(ttl, fetch)
+	c.now = clock.Now
+	return c, clock
+}
+
+func TestTokenCache_Caches(t *testing.T) {
+	var calls int32
+	c, clock := newTestCache(time.Minute, countingFetch(&calls))
+
+	for i := 0; i < 3; i++ {
+		token, err := c.get(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+	}
+
+	clock.Advance(time.Minute)
+	token, err := c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+}
+
+func TestTokenCache_SingleFlight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	c, _ := newTestCache(time.Minute, func(ctx context.Context) (string, error) {
+		<-release
+		return countingFetch(&calls)(ctx)
+	})
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], _ = c.get(context.Background())
+		}(i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, token := range tokens {
+		assert.Equal(t, "token-1", token)
+	}
+}
+
+func TestTokenCache_ProactiveRefresh(t *testing.T) {
+	var calls int32
+	c, clock := newTestCache(time.Minute, countingFetch(&calls))
+
+	token, err := c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	clock.Advance(50 * time.Second)
+	token, err = c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token, "current token is used while refreshing")
+
+	assert.Eventually(t, func() bool {
+		token, _ := c.get(context.Background())
+		return token == "token-2"
+	}, time.Second, time.Millisecond)
+}
+
+func TestTokenCache_Invalidate(t *testing.T) {
+	var calls int32
+	c, _ := newTestCache(time.Minute, countingFetch(&calls))
+
+	token, err := c.get(context.Background())
+	require.NoError(t, err)
+
+	c.invalidate("stale")
+	token, err = c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token, "a different token is not invalidated")
+
+	c.invalidate(token)
+	token, err = c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+}
+
+func TestTokenCache_FetchError(t *testing.T) {
+	fail := int32(1)
+	var calls int32
+	c, _ := newTestCache(time.Minute, func(ctx context.Context) (string, error) {
+		if atomic.LoadInt32(&fail) == 1 {
+			return "", errors.New("auth service down")
+		}
+		return countingFetch(&calls)(ctx)
+	})
+
+	_, err := c.get(context.Background())
+	assert.EqualError(t, err, "auth service down")
+
+	atomic.StoreInt32(&fail, 0)
+	token, err := c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+}
+
+func TestTokenCache_ContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c, _ := newTestCache(time.Minute, func(ctx context.Context) (string, error) {
+		<-release
+		return "token", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.get(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+)
+
+type chainProvider struct {
+	providers []credentials.PerRPCCredentials
+}
+
+// invalidatingChainProvider is a chainProvider with at least one provider that caches its token.
+type invalidatingChainProvider struct {
+	chainProvider
+}
+
+// NewChainProvider creates a provider that tries each of providers in order for every request, using the first one
+// that supplies a token. Transport security is required if any of the providers requires it. The provider is a
+// TokenInvalidator only if one of providers is.
+func NewChainProvider(providers ...credentials.PerRPCCredentials) credentials.PerRPCCredentials {
+	c := chainProvider{
+		providers: providers,
+	}
+	for _, p := range providers {
+		if _, ok := p.(TokenInvalidator); ok {
+			return invalidatingChainProvider{c}
+		}
+	}
+	return c
+}
+
+func (c chainProvider) RequireTransportSecurity() bool {
+	for _, p := range c.providers {
+		if p.RequireTransportSecurity() {
+			return true
+		}
+	}
+	return false
+}
+
+func (c chainProvider) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	if len(c.providers) == 0 {
+		return nil, errors.New("failed to get auth token: no providers in chain")
+	}
+
+	var errs []string
+	for _, p := range c.providers {
+		md, err := p.GetRequestMetadata(ctx, uri...)
+		if err == nil {
+			recordSentMetadata(ctx, md)
+			return md, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, fmt.Errorf("no provider in chain supplied a token: %s", strings.Join(errs, "; "))
+}
+
+// InvalidateToken passes token to each provider in the chain that caches its token.
+func (c invalidatingChainProvider) InvalidateToken(token string) {
+	for _, p := range c.providers {
+		if invalidator, ok := p.(TokenInvalidator); ok {
+			invalidator.InvalidateToken(token)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,15 +19,79 @@ type tableBasedTokenProvider struct {
 	username                 string
 	password                 string
 	requireTransportSecurity bool
+	tokenTTL                 time.Duration
+	credentials              CredentialsSource
+	cache                    *tokenCache
+}
+
+// TableBasedTokenProviderOption is an option for a table based token provider.
+type TableBasedTokenProviderOption func(*tableBasedTokenProvider)
+
+// defaultTokenTTL matches the default lifetime of Stargate's table based auth
+// tokens.
+const defaultTokenTTL = 30 * time.Minute
+
+// WithTokenTTL returns a TableBasedTokenProviderOption which sets how long a
+// token is cached before a new one is requested. It should not exceed the
+// lifetime of tokens issued by the auth service, which defaults to 30
+// minutes. Tokens are refreshed in the background once 80% of the TTL has
+// passed.
+func WithTokenTTL(ttl time.Duration) TableBasedTokenProviderOption {
+	return func(t *tableBasedTokenProvider) {
+		t.tokenTTL = ttl
+	}
 }
 
 type client struct {
-	serviceURL string
-	httpClient *http.Client
+	serviceURL   string
+	httpClient   *http.Client
+	headers      http.Header
+	tokenField   string
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// WithHTTPClient returns a TableBasedTokenProviderOption which sets the HTTP client used to call the auth service,
+// for instance to configure TLS roots, client certificates or a proxy. The default client has a 5 second timeout.
+func WithHTTPClient(httpClient *http.Client) TableBasedTokenProviderOption {
+	return func(t *tableBasedTokenProvider) {
+		t.client.httpClient = httpClient
+	}
 }
 
-type authResponse struct {
-	AuthToken string `json:"authToken"`
+// WithTransport returns a TableBasedTokenProviderOption which sets the transport of the HTTP client used to call the
+// auth service.
+func WithTransport(transport http.RoundTripper) TableBasedTokenProviderOption {
+	return func(t *tableBasedTokenProvider) {
+		httpClient := *t.client.httpClient
+		httpClient.Transport = transport
+		t.client.httpClient = &httpClient
+	}
+}
+
+// WithHeader returns a TableBasedTokenProviderOption which adds a header to every request to the auth service.
+func WithHeader(key, value string) TableBasedTokenProviderOption {
+	return func(t *tableBasedTokenProvider) {
+		t.client.headers.Add(key, value)
+	}
+}
+
+// WithTokenField returns a TableBasedTokenProviderOption which sets the field of the auth service's JSON response
+// holding the token. The default is `authToken`.
+func WithTokenField(field string) TableBasedTokenProviderOption {
+	return func(t *tableBasedTokenProvider) {
+		t.client.tokenField = field
+	}
+}
+
+// WithRetry returns a TableBasedTokenProviderOption which retries requests to the auth service up to maxRetries
+// times when it cannot be reached or responds with a 429 or 5xx status. The delay before the first retry is backoff
+// and doubles for each subsequent one.
+func WithRetry(maxRetries int, backoff time.Duration) TableBasedTokenProviderOption {
+	return func(t *tableBasedTokenProvider) {
+		t.client.maxRetries = maxRetries
+		t.client.retryBackoff = backoff
+	}
 }
 
 type authRequest struct {
@@ -36,60 +101,150 @@ type authRequest struct {
 
 // NewTableBasedTokenProvider creates a token provider intended to be used with Stargate's table based token authentication mechanism. This
 // function will generate a token by making a request to the provided Stargate auth-api URL and populating the `x-cassandra-token` header
-// with the returned token.
-func NewTableBasedTokenProvider(serviceURL, username, password string) credentials.PerRPCCredentials {
-	return tableBasedTokenProvider{
-		client:                   getClient(serviceURL),
-		username:                 username,
-		password:                 password,
-		requireTransportSecurity: true,
-	}
+// with the returned token. The token is cached and shared by all requests until it expires or is invalidated.
+func NewTableBasedTokenProvider(
+	serviceURL, username, password string,
+	opts ...TableBasedTokenProviderOption,
+) credentials.PerRPCCredentials {
+	return newTableBasedTokenProvider(serviceURL, username, password, true, opts...)
 }
 
 // NewTableBasedTokenProviderUnsafe is identical to NewTableBasedTokenProvider except that it will set requireTransportSecurity
 // to false for environments where transport security it not in use.
-func NewTableBasedTokenProviderUnsafe(serviceURL, username, password string) credentials.PerRPCCredentials {
-	return tableBasedTokenProvider{
+func NewTableBasedTokenProviderUnsafe(
+	serviceURL, username, password string,
+	opts ...TableBasedTokenProviderOption,
+) credentials.PerRPCCredentials {
+	return newTableBasedTokenProvider(serviceURL, username, password, false, opts...)
+}
+
+func newTableBasedTokenProvider(
+	serviceURL, username, password string,
+	requireTransportSecurity bool,
+	opts ...TableBasedTokenProviderOption,
+) *tableBasedTokenProvider {
+	t := &tableBasedTokenProvider{
 		client:                   getClient(serviceURL),
 		username:                 username,
 		password:                 password,
-		requireTransportSecurity: false,
+		requireTransportSecurity: requireTransportSecurity,
+		tokenTTL:                 defaultTokenTTL,
+	}
+
+	for _, opt := range opts {
+		opt(t)
 	}
+
+	t.cache = newTokenCache(t.tokenTTL, t.getToken)
+	return t
 }
 
-func (t tableBasedTokenProvider) RequireTransportSecurity() bool {
+func (t *tableBasedTokenProvider) RequireTransportSecurity() bool {
 	return t.requireTransportSecurity
 }
 
-func (t tableBasedTokenProvider) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
-	token, err := t.getToken(ctx)
+func (t *tableBasedTokenProvider) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := t.cache.get(ctx)
 	if err != nil {
 		log.WithError(err).Error("Failed to get auth token")
 		return nil, fmt.Errorf("failed to get auth token: %v", err)
 	}
-	return map[string]string{"x-cassandra-token": token}, nil
+	md := map[string]string{tokenHeader: token}
+	recordSentMetadata(ctx, md)
+	return md, nil
+}
+
+// InvalidateToken discards token if it is the cached token, so that the next request fetches a new one.
+func (t *tableBasedTokenProvider) InvalidateToken(token string) {
+	t.cache.invalidate(token)
 }
 
-// TODO: [doug] Figure out how to cache
-func (t tableBasedTokenProvider) getToken(ctx context.Context) (string, error) {
+func (t *tableBasedTokenProvider) getToken(ctx context.Context) (string, error) {
 	authReq := authRequest{
 		Username: t.username,
 		Password: t.password,
 	}
+	if t.credentials != nil {
+		username, password, err := t.credentials(ctx)
+		if err != nil {
+			return "", fmt.Errorf("error reading credentials: %v", err)
+		}
+		authReq = authRequest{Username: username, Password: password}
+	}
 	jsonString, err := json.Marshal(authReq)
 	if err != nil {
 		return "", fmt.Errorf("error marshalling request: %v", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.client.serviceURL, bytes.NewBuffer(jsonString))
+	delay := t.client.retryBackoff
+	for attempt := 0; ; attempt++ {
+		token, err := t.client.requestToken(ctx, jsonString)
+		if err == nil || attempt >= t.client.maxRetries || !isTransient(err) {
+			return token, err
+		}
+
+		log.WithError(err).Warnf("Auth request failed, retrying in %v", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// statusError is returned when the auth service responds with an unsuccessful status.
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("auth service returned status %d: %s", e.code, e.body)
+}
+
+// isTransient reports whether err is a failure to reach the auth service, or a
+// status indicating it is temporarily unable to respond, rather than a rejection of
+// the request.
+func isTransient(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= http.StatusInternalServerError
+	}
+	var ce *callError
+	return errors.As(err, &ce)
+}
+
+// callError is returned when the auth service could not be reached.
+type callError struct {
+	err error
+}
+
+func (e *callError) Error() string {
+	return fmt.Sprintf("error calling auth service: %v", e.err)
+}
+
+func (e *callError) Unwrap() error {
+	return e.err
+}
+
+func (c *client) requestToken(ctx context.Context, body []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serviceURL, bytes.NewReader(body))
 	if err != nil {
 		return "", fmt.Errorf("error creating request: %v", err)
 	}
 
 	req.Header.Add("Content-Type", "application/json")
-	response, err := t.client.httpClient.Do(req)
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	response, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("error calling auth service: %v", err)
+		return "", &callError{err: err}
 	}
 
 	defer func() {
@@ -99,18 +254,31 @@ func (t tableBasedTokenProvider) getToken(ctx context.Context) (string, error) {
 		}
 	}()
 
-	body, err := io.ReadAll(response.Body)
+	respBody, err := io.ReadAll(response.Body)
 	if err != nil {
-		return "", fmt.Errorf("error reading response body: %v", err)
+		return "", &callError{err: fmt.Errorf("error reading response body: %v", err)}
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return "", &statusError{code: response.StatusCode, body: string(bytes.TrimSpace(respBody))}
 	}
 
-	ar := authResponse{}
-	err = json.Unmarshal(body, &ar)
+	fields := map[string]json.RawMessage{}
+	err = json.Unmarshal(respBody, &fields)
 	if err != nil {
 		return "", fmt.Errorf("error unmarshalling response body: %v", err)
 	}
 
-	return ar.AuthToken, nil
+	var token string
+	if raw, ok := fields[c.tokenField]; ok {
+		if err := json.Unmarshal(raw, &token); err != nil {
+			return "", fmt.Errorf("error unmarshalling %s: %v", c.tokenField, err)
+		}
+	}
+	if token == "" {
+		return "", fmt.Errorf("no %s in auth service response", c.tokenField)
+	}
+
+	return token, nil
 }
 
 func getClient(serviceURL string) *client {
@@ -119,5 +287,7 @@ func getClient(serviceURL string) *client {
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
+		tokenField: "authToken",
+		headers:    http.Header{},
 	}
 }
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authServer is a fake auth service issuing token-1, token-2, ... after
+// failing the first failures requests with failureStatus.
+type authServer struct {
+	*httptest.Server
+	requests      int32
+	failures      int32
+	failureStatus int
+	lastRequest   *http.Request
+	lastBody      authRequest
+	response      func(token string) interface{}
+}
+
+func newAuthServer(t *testing.T) *authServer {
+	s := &authServer{
+		failureStatus: http.StatusServiceUnavailable,
+		response: func(token string) interface{} {
+			return map[string]string{"authToken": token}
+		},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&s.requests, 1)
+		s.lastRequest = r
+		if err := json.NewDecoder(r.Body).Decode(&s.lastBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if n <= atomic.LoadInt32(&s.failures) {
+			http.Error(w, "unavailable", s.failureStatus)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.response(fmt.Sprintf("token-%d", n-atomic.LoadInt32(&s.failures))))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestTableBasedTokenProvider(t *testing.T) {
+	server := newAuthServer(t)
+	p := NewTableBasedTokenProviderUnsafe(server.URL, "cassandra", "secret")
+
+	assert.Equal(t, "token-1", tokenOf(t, p))
+	assert.Equal(t, "token-1", tokenOf(t, p))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.requests))
+	assert.Equal(t, authRequest{Username: "cassandra", Password: "secret"}, server.lastBody)
+	assert.Equal(t, "application/json", server.lastRequest.Header.Get("Content-Type"))
+
+	p.(TokenInvalidator).InvalidateToken("token-1")
+	assert.Equal(t, "token-2", tokenOf(t, p))
+}
+
+func TestTableBasedTokenProvider_TokenTTL(t *testing.T) {
+	server := newAuthServer(t)
+	p := NewTableBasedTokenProviderUnsafe(server.URL, "cassandra", "secret", WithTokenTTL(time.Nanosecond))
+
+	assert.Equal(t, "token-1", tokenOf(t, p))
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, "token-2", tokenOf(t, p))
+}
+
+func TestTableBasedTokenProvider_HeadersAndTokenField(t *testing.T) {
+	server := newAuthServer(t)
+	server.response = func(token string) interface{} {
+		return map[string]interface{}{"token": token, "expires": 1800}
+	}
+	p := NewTableBasedTokenProviderUnsafe(server.URL, "cassandra", "secret",
+		WithHeader("X-Tenant", "tenant-1"),
+		WithHeader("X-Tenant", "tenant-2"),
+		WithTokenField("token"),
+	)
+
+	assert.Equal(t, "token-1", tokenOf(t, p))
+	assert.Equal(t, []string{"tenant-1", "tenant-2"}, server.lastRequest.Header.Values("X-Tenant"))
+}
+
+func TestTableBasedTokenProvider_MissingToken(t *testing.T) {
+	server := newAuthServer(t)
+	p := NewTableBasedTokenProviderUnsafe(server.URL, "cassandra", "secret", WithTokenField("token"))
+
+	_, err := p.GetRequestMetadata(context.Background())
+	assert.EqualError(t, err, "failed to get auth token: no token in auth service response")
+}
+
+func TestTableBasedTokenProvider_Retry(t *testing.T) {
+	server := newAuthServer(t)
+	server.failures = 2
+	p := NewTableBasedTokenProviderUnsafe(server.URL, "cassandra", "secret", WithRetry(2, time.Millisecond))
+
+	assert.Equal(t, "token-1", tokenOf(t, p))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&server.requests))
+}
+
+func TestTableBasedTokenProvider_RetryExhausted(t *testing.T) {
+	server := newAuthServer(t)
+	server.failures = 3
+	p := NewTableBasedTokenProviderUnsafe(server.URL, "cassandra", "secret", WithRetry(1, time.Millisecond))
+
+	_, err := p.GetRequestMetadata(context.Background())
+	assert.EqualError(t, err, "failed to get auth token: auth service returned status 503: unavailable")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.requests))
+}
+
+func TestTableBasedTokenProvider_NoRetryOnRejection(t *testing.T) {
+	server := newAuthServer(t)
+	server.failures = 1
+	server.failureStatus = http.StatusUnauthorized
+	p := NewTableBasedTokenProviderUnsafe(server.URL, "cassandra", "wrong", WithRetry(3, time.Millisecond))
+
+	_, err := p.GetRequestMetadata(context.Background())
+	assert.EqualError(t, err, "failed to get auth token: auth service returned status 401: unavailable")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.requests))
+}
+
+func TestTableBasedTokenProvider_RetryUnreachable(t *testing.T) {
+	server := newAuthServer(t)
+	url := server.URL
+	server.Close()
+
+	p := NewTableBasedTokenProviderUnsafe(url, "cassandra", "secret", WithRetry(1, time.Millisecond))
+	_, err := p.GetRequestMetadata(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error calling auth service")
+}
+
+// roundTripFunc is an http.RoundTripper implemented by a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTableBasedTokenProvider_HTTPClient(t *testing.T) {
+	server := newAuthServer(t)
+
+	var viaTransport int32
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&viaTransport, 1)
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	p := NewTableBasedTokenProviderUnsafe(server.URL, "cassandra", "secret", WithTransport(transport))
+	assert.Equal(t, "token-1", tokenOf(t, p))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&viaTransport))
+
+	p = NewTableBasedTokenProviderUnsafe(server.URL, "cassandra", "secret",
+		WithHTTPClient(&http.Client{Transport: transport}))
+	assert.Equal(t, "token-2", tokenOf(t, p))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&viaTransport))
+}
+
+func TestTableBasedTokenProvider_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"authToken": "secure"})
+	}))
+	defer server.Close()
+
+	p := NewTableBasedTokenProvider(server.URL, "cassandra", "secret")
+	assert.True(t, p.RequireTransportSecurity())
+	_, err := p.GetRequestMetadata(context.Background())
+	assert.Error(t, err, "the test server's certificate is not trusted by default")
+
+	p = NewTableBasedTokenProvider(server.URL, "cassandra", "secret", WithHTTPClient(server.Client()))
+	assert.Equal(t, "secure", tokenOf(t, p))
+}
+
+func TestTableBasedTokenProvider_CredentialsSource(t *testing.T) {
+	server := newAuthServer(t)
+	t.Setenv("STARGATE_TEST_USERNAME", "env-user")
+	t.Setenv("STARGATE_TEST_PASSWORD", "env-secret")
+
+	p := NewTableBasedTokenProviderUnsafe(server.URL, "", "",
+		WithCredentialsSource(EnvCredentials("STARGATE_TEST_USERNAME", "STARGATE_TEST_PASSWORD")))
+	assert.Equal(t, "token-1", tokenOf(t, p))
+	assert.Equal(t, authRequest{Username: "env-user", Password: "env-secret"}, server.lastBody)
+}
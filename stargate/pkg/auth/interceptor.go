@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// sentMetadataKey is the context key of the sentMetadata of a call.
+type sentMetadataKey struct{}
+
+// sentMetadata holds the metadata last supplied by a provider for a call, so
+// that the token invalidated is the one the call actually sent.
+type sentMetadata struct {
+	mu sync.Mutex
+	md map[string]string
+}
+
+func withSentMetadata(ctx context.Context) (context.Context, *sentMetadata) {
+	sent := &sentMetadata{}
+	return context.WithValue(ctx, sentMetadataKey{}, sent), sent
+}
+
+// recordSentMetadata records md as the metadata sent by the call of ctx, if
+// it is made through a reauth interceptor.
+func recordSentMetadata(ctx context.Context, md map[string]string) {
+	if sent, ok := ctx.Value(sentMetadataKey{}).(*sentMetadata); ok {
+		sent.mu.Lock()
+		sent.md = md
+		sent.mu.Unlock()
+	}
+}
+
+func (s *sentMetadata) get() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.md
+}
+
+// NewReauthInterceptor returns a unary client interceptor which, when a call
+// fails with codes.Unauthenticated, invalidates the token sent by creds and
+// retries the call once with a freshly fetched token. creds must be the same
+// provider passed to grpc.WithPerRPCCredentials. Calls are not retried if
+// creds does not implement TokenInvalidator, since it would send the same
+// token again.
+//
+// The providers of this package record the token they supply for each call.
+// For other providers, the token invalidated is the one they supply once the
+// call has failed, which is the one sent unless it has been refreshed since.
+func NewReauthInterceptor(creds credentials.PerRPCCredentials) grpc.UnaryClientInterceptor {
+	invalidator, ok := creds.(TokenInvalidator)
+	if !ok {
+		return func(
+			ctx context.Context, method string, req, reply interface{},
+			cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+		) error {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+	}
+
+	return func(
+		ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		ctx, sent := withSentMetadata(ctx)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if status.Code(err) != codes.Unauthenticated {
+			return err
+		}
+
+		md := sent.get()
+		if md == nil {
+			var mdErr error
+			if md, mdErr = creds.GetRequestMetadata(ctx); mdErr != nil {
+				return err
+			}
+		}
+
+		log.WithError(err).Debug("Token rejected, reauthenticating")
+		for _, token := range md {
+			invalidator.InvalidateToken(token)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// rotatingProvider issues token-1, token-2, ... each time its token is
+// invalidated.
+type rotatingProvider struct {
+	generation int32
+}
+
+func (p *rotatingProvider) token() string {
+	return fmt.Sprintf("token-%d", atomic.LoadInt32(&p.generation))
+}
+
+func (p *rotatingProvider) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	md := map[string]string{"x-cassandra-token": p.token()}
+	recordSentMetadata(ctx, md)
+	return md, nil
+}
+
+func (p *rotatingProvider) RequireTransportSecurity() bool {
+	return false
+}
+
+func (p *rotatingProvider) InvalidateToken(token string) {
+	if token == p.token() {
+		atomic.AddInt32(&p.generation, 1)
+	}
+}
+
+// acceptingInvoker rejects every token other than accepted and records the
+// tokens it was called with.
+func acceptingInvoker(creds credentials.PerRPCCredentials, accepted string, seen *[]string) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := creds.GetRequestMetadata(ctx)
+		token := md["x-cassandra-token"]
+		*seen = append(*seen, token)
+		if token != accepted {
+			return status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return nil
+	}
+}
+
+func TestReauthInterceptor(t *testing.T) {
+	creds := &rotatingProvider{generation: 1}
+	interceptor := NewReauthInterceptor(creds)
+
+	var seen []string
+	err := interceptor(context.Background(), "/stargate.Stargate/ExecuteQuery", nil, nil, nil,
+		acceptingInvoker(creds, "token-2", &seen))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-1", "token-2"}, seen)
+}
+
+func TestReauthInterceptor_RefreshedBeforeSend(t *testing.T) {
+	creds := &rotatingProvider{generation: 1}
+	interceptor := NewReauthInterceptor(creds)
+
+	var seen []string
+	invoker := acceptingInvoker(creds, "token-3", &seen)
+	err := interceptor(context.Background(), "/stargate.Stargate/ExecuteQuery", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			if len(seen) == 0 {
+				// The token is refreshed in the background as the call starts.
+				atomic.AddInt32(&creds.generation, 1)
+			}
+			return invoker(ctx, method, req, reply, cc, opts...)
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-2", "token-3"}, seen)
+}
+
+// unrecordedProvider is a rotatingProvider which does not record the
+// metadata it supplies.
+type unrecordedProvider struct {
+	*rotatingProvider
+}
+
+func (p unrecordedProvider) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"x-cassandra-token": p.token()}, nil
+}
+
+func TestReauthInterceptor_UnrecordedProvider(t *testing.T) {
+	creds := unrecordedProvider{&rotatingProvider{generation: 1}}
+	interceptor := NewReauthInterceptor(creds)
+
+	var seen []string
+	err := interceptor(context.Background(), "/stargate.Stargate/ExecuteQuery", nil, nil, nil,
+		acceptingInvoker(creds, "token-2", &seen))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-1", "token-2"}, seen)
+}
+
+func TestReauthInterceptor_RetriesOnce(t *testing.T) {
+	creds := &rotatingProvider{generation: 1}
+	interceptor := NewReauthInterceptor(creds)
+
+	var seen []string
+	err := interceptor(context.Background(), "/stargate.Stargate/ExecuteQuery", nil, nil, nil,
+		acceptingInvoker(creds, "token-9", &seen))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, []string{"token-1", "token-2"}, seen)
+}
+
+func TestReauthInterceptor_OtherErrors(t *testing.T) {
+	creds := &rotatingProvider{generation: 1}
+	interceptor := NewReauthInterceptor(creds)
+
+	calls := 0
+	err := interceptor(context.Background(), "/stargate.Stargate/ExecuteQuery", nil, nil, nil,
+		func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			calls++
+			return status.Error(codes.Unavailable, "down")
+		})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestReauthInterceptor_StaticToken(t *testing.T) {
+	creds := NewStaticTokenProviderUnsafe("token-1")
+	interceptor := NewReauthInterceptor(creds)
+
+	var seen []string
+	err := interceptor(context.Background(), "/stargate.Stargate/ExecuteQuery", nil, nil, nil,
+		acceptingInvoker(creds, "token-2", &seen))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, []string{"token-1"}, seen)
+}
+
+func TestReauthInterceptor_StaticChain(t *testing.T) {
+	creds := NewChainProvider(NewStaticTokenProviderUnsafe("token-1"), NewStaticTokenProviderUnsafe("token-2"))
+	interceptor := NewReauthInterceptor(creds)
+
+	var seen []string
+	err := interceptor(context.Background(), "/stargate.Stargate/ExecuteQuery", nil, nil, nil,
+		acceptingInvoker(creds, "token-2", &seen))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, []string{"token-1"}, seen)
+}
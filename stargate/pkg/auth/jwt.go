@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/credentials"
+)
+
+type jwtProvider struct {
+	header                   string
+	scheme                   string
+	refreshBefore            time.Duration
+	requireTransportSecurity bool
+	cache                    *tokenCache
+}
+
+// JWTProviderOption is an option for a JWT provider.
+type JWTProviderOption func(*jwtProvider)
+
+// defaultRefreshBefore is how long before a JWT expires that it is refreshed.
+const defaultRefreshBefore = time.Minute
+
+// minJWTRefreshInterval is the least time between fetching a JWT and
+// refreshing it, so that a token that is already expired when fetched, for
+// instance because of clock skew, is not fetched again on every request.
+const minJWTRefreshInterval = 5 * time.Second
+
+// WithJWTHeader returns a JWTProviderOption which sends the token in header, prefixed by scheme and a space unless
+// scheme is empty. The default is the `authorization` header with the `Bearer` scheme.
+func WithJWTHeader(header, scheme string) JWTProviderOption {
+	return func(j *jwtProvider) {
+		j.header = strings.ToLower(header)
+		j.scheme = scheme
+	}
+}
+
+// WithRefreshBefore returns a JWTProviderOption which sets how long before the token's `exp` claim a new token is
+// fetched in the background. The default is one minute. Tokens living for less than that are refreshed after most of
+// their lifetime instead.
+func WithRefreshBefore(d time.Duration) JWTProviderOption {
+	return func(j *jwtProvider) {
+		j.refreshBefore = d
+	}
+}
+
+// NewJWTProvider creates a token provider for deployments that front Stargate with JWT authentication. The token
+// returned by fetch is sent as a bearer token in the `authorization` header and cached until shortly before the
+// expiry given by its `exp` claim. Tokens without an `exp` claim, or that are not JWTs, are cached until invalidated.
+// Tokens are never sent over a connection without transport security.
+func NewJWTProvider(fetch func(ctx context.Context) (string, error), opts ...JWTProviderOption) credentials.PerRPCCredentials {
+	return newJWTProvider(fetch, true, opts...)
+}
+
+// NewJWTProviderUnsafe is identical to NewJWTProvider except that it will set requireTransportSecurity
+// to false for environments where transport security it not in use.
+func NewJWTProviderUnsafe(fetch func(ctx context.Context) (string, error), opts ...JWTProviderOption) credentials.PerRPCCredentials {
+	return newJWTProvider(fetch, false, opts...)
+}
+
+func newJWTProvider(
+	fetch func(ctx context.Context) (string, error),
+	requireTransportSecurity bool,
+	opts ...JWTProviderOption,
+) *jwtProvider {
+	j := &jwtProvider{
+		header:                   "authorization",
+		scheme:                   "Bearer",
+		refreshBefore:            defaultRefreshBefore,
+		requireTransportSecurity: requireTransportSecurity,
+	}
+
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	j.cache = &tokenCache{
+		fetch:    fetch,
+		lifetime: j.lifetime,
+		now:      time.Now,
+	}
+	return j
+}
+
+func (j *jwtProvider) RequireTransportSecurity() bool {
+	return j.requireTransportSecurity
+}
+
+func (j *jwtProvider) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	// gRPC also refuses to use credentials requiring transport security on an insecure connection, but check the
+	// connection's security level when it is known in case of a misconfigured transport.
+	if ri, ok := credentials.RequestInfoFromContext(ctx); ok && j.requireTransportSecurity {
+		if err := credentials.CheckSecurityLevel(ri.AuthInfo, credentials.PrivacyAndIntegrity); err != nil {
+			return nil, fmt.Errorf("refusing to send token over insecure connection: %v", err)
+		}
+	}
+
+	token, err := j.cache.get(ctx)
+	if err != nil {
+		log.WithError(err).Error("Failed to get auth token")
+		return nil, fmt.Errorf("failed to get auth token: %v", err)
+	}
+	md := map[string]string{j.header: j.value(token)}
+	recordSentMetadata(ctx, md)
+	return md, nil
+}
+
+// InvalidateToken discards the cached token if token is the header value sent with it.
+func (j *jwtProvider) InvalidateToken(token string) {
+	if j.scheme != "" {
+		token = strings.TrimPrefix(token, j.scheme+" ")
+	}
+	j.cache.invalidate(token)
+}
+
+func (j *jwtProvider) value(token string) string {
+	if j.scheme == "" {
+		return token
+	}
+	return j.scheme + " " + token
+}
+
+// lifetime refreshes a token refreshBefore its expiry, but no earlier than
+// refreshFraction of its lifetime or minJWTRefreshInterval after it was
+// fetched. A token expiring sooner than that is used until it is refreshed,
+// leaving the gateway to reject it if it has really expired.
+func (j *jwtProvider) lifetime(token string, fetchedAt time.Time) (time.Time, time.Time) {
+	exp, ok := jwtExpiry(token)
+	if !ok {
+		return time.Time{}, time.Time{}
+	}
+
+	refreshAt := exp.Add(-j.refreshBefore)
+	if earliest := fetchedAt.Add(time.Duration(float64(exp.Sub(fetchedAt)) * refreshFraction)); refreshAt.Before(earliest) {
+		refreshAt = earliest
+	}
+	if earliest := fetchedAt.Add(minJWTRefreshInterval); refreshAt.Before(earliest) {
+		refreshAt = earliest
+	}
+	if exp.Before(refreshAt) {
+		exp = refreshAt
+	}
+	return refreshAt, exp
+}
+
+// jwtExpiry returns the time given by the `exp` claim of token, without
+// verifying its signature.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	sec := int64(exp)
+	return time.Unix(sec, int64((exp-float64(sec))*float64(time.Second))), true
+}
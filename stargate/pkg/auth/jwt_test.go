@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testJWT(claims string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		enc.EncodeToString([]byte(claims)) + "." +
+		enc.EncodeToString([]byte("signature"))
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp, ok := jwtExpiry(testJWT(`{"sub":"app","exp":1600000000}`))
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(1600000000, 0), exp)
+
+	exp, ok = jwtExpiry(testJWT(`{"exp":1600000000.5}`))
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(1600000000, 500000000), exp)
+
+	_, ok = jwtExpiry(testJWT(`{"sub":"app"}`))
+	assert.False(t, ok)
+	_, ok = jwtExpiry("opaque-token")
+	assert.False(t, ok)
+	_, ok = jwtExpiry("a.!!!.c")
+	assert.False(t, ok)
+}
+
+func TestJWTProvider(t *testing.T) {
+	var calls int32
+	p := NewJWTProviderUnsafe(func(ctx context.Context) (string, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return testJWT(fmt.Sprintf(`{"n":%d,"exp":%d}`, n, time.Now().Add(time.Hour).Unix())), nil
+	})
+	assert.False(t, p.RequireTransportSecurity())
+
+	md, err := p.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	value := md["authorization"]
+	assert.Regexp(t, `^Bearer \S+\.\S+\.\S+$`, value)
+
+	md, err = p.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, value, md["authorization"])
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	p.(TokenInvalidator).InvalidateToken(value)
+	md, err = p.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.NotEqual(t, value, md["authorization"])
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestJWTProvider_RefreshBeforeExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	var calls int32
+	p := NewJWTProvider(func(ctx context.Context) (string, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return testJWT(fmt.Sprintf(`{"n":%d,"exp":%d}`, n, clock.Now().Add(time.Hour).Unix())), nil
+	}, WithRefreshBefore(time.Minute))
+	p.(*jwtProvider).cache.now = clock.Now
+	assert.True(t, p.RequireTransportSecurity())
+
+	_, err := p.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+
+	clock.Advance(58 * time.Minute)
+	_, err = p.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	clock.Advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		_, err := p.GetRequestMetadata(context.Background())
+		return err == nil && atomic.LoadInt32(&calls) > 1
+	}, time.Second, time.Millisecond, "a token within the refresh window is refreshed")
+}
+
+func TestJWTProvider_ShortLivedToken(t *testing.T) {
+	fetchedAt := time.Unix(1600000000, 0)
+	j := newJWTProvider(nil, false, WithRefreshBefore(time.Minute))
+	tests := []struct {
+		name      string
+		exp       time.Time
+		refreshAt time.Time
+		expiresAt time.Time
+	}{
+		{"long lived", fetchedAt.Add(time.Hour), fetchedAt.Add(59 * time.Minute), fetchedAt.Add(time.Hour)},
+		{"shorter than refresh window", fetchedAt.Add(30 * time.Second), fetchedAt.Add(24 * time.Second), fetchedAt.Add(30 * time.Second)},
+		{"already expired", fetchedAt.Add(-time.Minute), fetchedAt.Add(5 * time.Second), fetchedAt.Add(5 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshAt, expiresAt := j.lifetime(testJWT(fmt.Sprintf(`{"exp":%d}`, tt.exp.Unix())), fetchedAt)
+			assert.Equal(t, tt.refreshAt, refreshAt)
+			assert.Equal(t, tt.expiresAt, expiresAt)
+		})
+	}
+}
+
+func TestJWTProvider_ExpiredTokenNotFetchedPerRequest(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	var calls int32
+	p := NewJWTProviderUnsafe(func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return testJWT(fmt.Sprintf(`{"exp":%d}`, clock.Now().Add(-time.Minute).Unix())), nil
+	})
+	p.(*jwtProvider).cache.now = clock.Now
+
+	for i := 0; i < 10; i++ {
+		_, err := p.GetRequestMetadata(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestJWTProvider_Header(t *testing.T) {
+	p := NewJWTProviderUnsafe(func(ctx context.Context) (string, error) {
+		return "opaque-token", nil
+	}, WithJWTHeader("X-Cassandra-Token", ""))
+
+	md, err := p.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"x-cassandra-token": "opaque-token"}, md)
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+const tokenHeader = "x-cassandra-token"
+
+type tokenFuncProvider struct {
+	fetch                    func(ctx context.Context) (string, error)
+	requireTransportSecurity bool
+}
+
+// NewCallbackTokenProvider will call fetch for every request and use the token it returns to populate the
+// `x-cassandra-token` header. fetch is responsible for any caching.
+func NewCallbackTokenProvider(fetch func(ctx context.Context) (string, error)) credentials.PerRPCCredentials {
+	return tokenFuncProvider{
+		fetch:                    fetch,
+		requireTransportSecurity: true,
+	}
+}
+
+// NewCallbackTokenProviderUnsafe is identical to NewCallbackTokenProvider except that it will set
+// requireTransportSecurity to false for environments where transport security it not in use.
+func NewCallbackTokenProviderUnsafe(fetch func(ctx context.Context) (string, error)) credentials.PerRPCCredentials {
+	return tokenFuncProvider{
+		fetch:                    fetch,
+		requireTransportSecurity: false,
+	}
+}
+
+// NewFileTokenProvider will read the token from the file at path, such as a mounted Kubernetes secret. The file is
+// read again whenever it changes, so the token can be rotated without restarting.
+func NewFileTokenProvider(path string) credentials.PerRPCCredentials {
+	return NewCallbackTokenProvider(fileToken(path))
+}
+
+// NewFileTokenProviderUnsafe is identical to NewFileTokenProvider except that it will set requireTransportSecurity
+// to false for environments where transport security it not in use.
+func NewFileTokenProviderUnsafe(path string) credentials.PerRPCCredentials {
+	return NewCallbackTokenProviderUnsafe(fileToken(path))
+}
+
+// NewEnvTokenProvider will read the token from the environment variable name for every request.
+func NewEnvTokenProvider(name string) credentials.PerRPCCredentials {
+	return NewCallbackTokenProvider(envToken(name))
+}
+
+// NewEnvTokenProviderUnsafe is identical to NewEnvTokenProvider except that it will set requireTransportSecurity
+// to false for environments where transport security it not in use.
+func NewEnvTokenProviderUnsafe(name string) credentials.PerRPCCredentials {
+	return NewCallbackTokenProviderUnsafe(envToken(name))
+}
+
+func (t tokenFuncProvider) RequireTransportSecurity() bool {
+	return t.requireTransportSecurity
+}
+
+func (t tokenFuncProvider) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := t.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth token: %w", err)
+	}
+	if token == "" {
+		return nil, errors.New("failed to get auth token: token is empty")
+	}
+	return map[string]string{tokenHeader: token}, nil
+}
+
+func fileToken(path string) func(ctx context.Context) (string, error) {
+	f := &watchedFile{path: path}
+	return func(ctx context.Context) (string, error) {
+		contents, err := f.read()
+		if err != nil {
+			return "", err
+		}
+		return string(bytes.TrimSpace(contents)), nil
+	}
+}
+
+func envToken(name string) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		token, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return strings.TrimSpace(token), nil
+	}
+}
+
+// CredentialsSource supplies the username and password used by a table based token provider to request a token.
+type CredentialsSource func(ctx context.Context) (username, password string, err error)
+
+// WithCredentialsSource returns a TableBasedTokenProviderOption which reads the username and password from source
+// every time a token is requested, instead of using the ones passed to the constructor.
+func WithCredentialsSource(source CredentialsSource) TableBasedTokenProviderOption {
+	return func(t *tableBasedTokenProvider) {
+		t.credentials = source
+	}
+}
+
+// FileCredentials returns a CredentialsSource which reads a JSON object with `username` and `password` fields from
+// the file at path. The file is read again whenever it changes.
+func FileCredentials(path string) CredentialsSource {
+	f := &watchedFile{path: path}
+	return func(ctx context.Context) (string, string, error) {
+		contents, err := f.read()
+		if err != nil {
+			return "", "", err
+		}
+
+		var creds authRequest
+		if err := json.Unmarshal(contents, &creds); err != nil {
+			return "", "", fmt.Errorf("error parsing credentials file %s: %w", path, err)
+		}
+		return creds.Username, creds.Password, nil
+	}
+}
+
+// EnvCredentials returns a CredentialsSource which reads the username and password from the given environment
+// variables.
+func EnvCredentials(usernameVar, passwordVar string) CredentialsSource {
+	return func(ctx context.Context) (string, string, error) {
+		username, ok := os.LookupEnv(usernameVar)
+		if !ok {
+			return "", "", fmt.Errorf("environment variable %s is not set", usernameVar)
+		}
+		password, ok := os.LookupEnv(passwordVar)
+		if !ok {
+			return "", "", fmt.Errorf("environment variable %s is not set", passwordVar)
+		}
+		return username, password, nil
+	}
+}
+
+// watchedFile caches the contents of a file, reading it again when its size or
+// modification time changes.
+type watchedFile struct {
+	path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	size     int64
+	contents []byte
+}
+
+func (f *watchedFile) read() ([]byte, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.contents != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.contents, nil
+	}
+
+	contents, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	f.contents, f.modTime, f.size = contents, info.ModTime(), info.Size()
+	return contents, nil
+}
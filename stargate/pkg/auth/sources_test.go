@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+)
+
+func tokenOf(t *testing.T, p credentials.PerRPCCredentials) string {
+	t.Helper()
+	md, err := p.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	return md[tokenHeader]
+}
+
+func TestFileTokenProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("token-1\n"), 0600))
+
+	p := NewFileTokenProvider(path)
+	assert.True(t, p.RequireTransportSecurity())
+	assert.Equal(t, "token-1", tokenOf(t, p))
+
+	require.NoError(t, os.WriteFile(path, []byte("token-22\n"), 0600))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+	assert.Equal(t, "token-22", tokenOf(t, p))
+
+	require.NoError(t, os.Remove(path))
+	_, err := p.GetRequestMetadata(context.Background())
+	assert.Error(t, err)
+}
+
+func TestEnvTokenProvider(t *testing.T) {
+	p := NewEnvTokenProviderUnsafe("STARGATE_TEST_TOKEN")
+	assert.False(t, p.RequireTransportSecurity())
+
+	_, err := p.GetRequestMetadata(context.Background())
+	assert.EqualError(t, err, "failed to get auth token: environment variable STARGATE_TEST_TOKEN is not set")
+
+	t.Setenv("STARGATE_TEST_TOKEN", "token-1")
+	assert.Equal(t, "token-1", tokenOf(t, p))
+
+	t.Setenv("STARGATE_TEST_TOKEN", "")
+	_, err = p.GetRequestMetadata(context.Background())
+	assert.EqualError(t, err, "failed to get auth token: token is empty")
+}
+
+func TestCallbackTokenProvider(t *testing.T) {
+	p := NewCallbackTokenProvider(func(ctx context.Context) (string, error) {
+		return "token-1", nil
+	})
+	assert.Equal(t, "token-1", tokenOf(t, p))
+
+	p = NewCallbackTokenProvider(func(ctx context.Context) (string, error) {
+		return "", errors.New("vault sealed")
+	})
+	_, err := p.GetRequestMetadata(context.Background())
+	assert.EqualError(t, err, "failed to get auth token: vault sealed")
+}
+
+func TestChainProvider(t *testing.T) {
+	failing := NewCallbackTokenProviderUnsafe(func(ctx context.Context) (string, error) {
+		return "", errors.New("not configured")
+	})
+
+	p := NewChainProvider(failing, NewStaticTokenProviderUnsafe("token-1"), NewStaticTokenProviderUnsafe("token-2"))
+	assert.False(t, p.RequireTransportSecurity())
+	assert.Equal(t, "token-1", tokenOf(t, p))
+
+	p = NewChainProvider(failing, NewStaticTokenProvider("token-1"))
+	assert.True(t, p.RequireTransportSecurity())
+
+	p = NewChainProvider(failing, failing)
+	_, err := p.GetRequestMetadata(context.Background())
+	assert.EqualError(t, err, "no provider in chain supplied a token: "+
+		"failed to get auth token: not configured; failed to get auth token: not configured")
+}
+
+func TestChainProvider_InvalidateToken(t *testing.T) {
+	rotating := &rotatingProvider{generation: 1}
+	p := NewChainProvider(NewStaticTokenProviderUnsafe("static"), rotating)
+
+	p.(TokenInvalidator).InvalidateToken("token-1")
+	assert.Equal(t, "token-2", rotating.token())
+
+	p = NewChainProvider(NewStaticTokenProviderUnsafe("static"), NewEnvTokenProviderUnsafe("STARGATE_TOKEN"))
+	_, ok := p.(TokenInvalidator)
+	assert.False(t, ok, "no provider in the chain caches its token")
+}
+
+func TestFileCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"username": "cassandra", "password": "secret"}`), 0600))
+
+	username, password, err := FileCredentials(path)(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "cassandra", username)
+	assert.Equal(t, "secret", password)
+
+	require.NoError(t, os.WriteFile(path, []byte(`not json`), 0600))
+	_, _, err = FileCredentials(path)(context.Background())
+	assert.Error(t, err)
+}
+
+func TestEnvCredentials(t *testing.T) {
+	source := EnvCredentials("STARGATE_TEST_USERNAME", "STARGATE_TEST_PASSWORD")
+
+	t.Setenv("STARGATE_TEST_USERNAME", "cassandra")
+	_, _, err := source(context.Background())
+	assert.EqualError(t, err, "environment variable STARGATE_TEST_PASSWORD is not set")
+
+	t.Setenv("STARGATE_TEST_PASSWORD", "secret")
+	username, password, err := source(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "cassandra", username)
+	assert.Equal(t, "secret", password)
+}
@@ -33,5 +33,5 @@ func (s staticTokenProvider) RequireTransportSecurity() bool {
 }
 
 func (s staticTokenProvider) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
-	return map[string]string{"x-cassandra-token": s.token}, nil
+	return map[string]string{tokenHeader: s.token}, nil
 }
@@ -6,49 +6,109 @@ import (
 	"time"
 
 	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 )
 
 const defaultTimeout = time.Second * 10
 
 type StargateClient struct {
-	client  pb.StargateClient
-	timeout time.Duration
+	client      pb.StargateClient
+	conns       []*grpc.ClientConn
+	timeout     time.Duration
+	retryPolicy RetryPolicy
+	idempotent  bool
+	defaults    defaults
+
+	speculativePolicy SpeculativeExecutionPolicy
+	speculativeStats  *speculativeStats
+
+	tracer         trace.Tracer
+	metrics        Metrics
+	queryLog       *queryLog
+	warningHandler WarningHandler
 }
 
 // StargateClientOption is an option for a StargateClient.
 type StargateClientOption func(*StargateClient)
 
+// CallOption is an option for a single call to ExecuteQueryWithOptions or
+// ExecuteBatchWithOptions.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	idempotent *bool
+	tracing    bool
+}
+
 // NewStargateClientWithConn creates a new StargateClient with the specified
 // gRPC connection and options.
 func NewStargateClientWithConn(
 	conn grpc.ClientConnInterface,
 	opts ...StargateClientOption,
 ) (*StargateClient, error) {
-	c := pb.NewStargateClient(conn)
+	return newStargateClient(pb.NewStargateClient(conn), opts...), nil
+}
+
+func newStargateClient(c pb.StargateClient, opts ...StargateClientOption) *StargateClient {
 	sc := &StargateClient{
-		client:  c,
-		timeout: defaultTimeout,
+		client:           c,
+		timeout:          defaultTimeout,
+		retryPolicy:      NewFallthroughRetryPolicy(),
+		speculativeStats: &speculativeStats{},
+		metrics:          nopMetrics{},
+		warningHandler:   LogWarnings,
 	}
 
 	for _, opt := range opts {
 		opt(sc)
 	}
 
-	return sc, nil
+	return sc
 }
 
 func (s *StargateClient) ExecuteQuery(query *pb.Query) (*pb.Response, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
-	return s.ExecuteQueryWithContext(query, ctx)
+	return s.ExecuteQueryWithOptions(query, ctx)
 }
 
 func (s *StargateClient) ExecuteQueryWithContext(query *pb.Query, ctx context.Context) (*pb.Response, error) {
-	resp, err := s.client.ExecuteQuery(ctx, query)
+	return s.ExecuteQueryWithOptions(query, ctx)
+}
+
+// ExecuteQueryWithOptions executes query like ExecuteQueryWithContext, applying
+// opts to this call only.
+func (s *StargateClient) ExecuteQueryWithOptions(query *pb.Query, ctx context.Context, opts ...CallOption) (*pb.Response, error) {
+	o := s.callOptions(opts)
+	query = o.applyToQuery(s.defaults.applyToQuery(query))
+	ctx, endSpan := s.startQuerySpan(ctx, query)
+	finished := s.measure(KindQuery)
+	logged := s.startQueryLog(ctx, query)
+	idempotent := s.isIdempotent(o, queryIdempotence(query))
+
+	resp, err := s.executeWithRetries(ctx, KindQuery, query.GetParameters().GetConsistency(), idempotent,
+		func(ctx context.Context, consistency *pb.ConsistencyValue) (*pb.Response, error) {
+			q := query
+			if consistency != nil {
+				q = proto.Clone(query).(*pb.Query)
+				if q.Parameters == nil {
+					q.Parameters = &pb.QueryParameters{}
+				}
+				q.Parameters.Consistency = consistency
+			}
+			return s.speculate(ctx, KindQuery, idempotent, func(ctx context.Context) (*pb.Response, error) {
+				return s.client.ExecuteQuery(ctx, q)
+			})
+		})
+	endSpan(resp, err)
+	finished(resp, err)
+	logged(resp, err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %v", err)
+		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
+	s.handleQueryWarnings(ctx, query, resp)
 
 	return resp, nil
 }
@@ -56,18 +116,56 @@ func (s *StargateClient) ExecuteQueryWithContext(query *pb.Query, ctx context.Co
 func (s *StargateClient) ExecuteBatch(batch *pb.Batch) (*pb.Response, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
-	return s.ExecuteBatchWithContext(batch, ctx)
+	return s.ExecuteBatchWithOptions(batch, ctx)
 }
 
 func (s *StargateClient) ExecuteBatchWithContext(batch *pb.Batch, ctx context.Context) (*pb.Response, error) {
-	resp, err := s.client.ExecuteBatch(ctx, batch)
+	return s.ExecuteBatchWithOptions(batch, ctx)
+}
+
+// ExecuteBatchWithOptions executes batch like ExecuteBatchWithContext, applying
+// opts to this call only.
+func (s *StargateClient) ExecuteBatchWithOptions(batch *pb.Batch, ctx context.Context, opts ...CallOption) (*pb.Response, error) {
+	o := s.callOptions(opts)
+	batch = o.applyToBatch(s.defaults.applyToBatch(batch))
+	ctx, endSpan := s.startBatchSpan(ctx, batch)
+	finished := s.measure(KindBatch)
+	logged := s.startBatchLog(ctx, batch)
+	idempotent := s.isIdempotent(o, batchIdempotence(batch))
+
+	resp, err := s.executeWithRetries(ctx, KindBatch, batch.GetParameters().GetConsistency(), idempotent,
+		func(ctx context.Context, consistency *pb.ConsistencyValue) (*pb.Response, error) {
+			b := batch
+			if consistency != nil {
+				b = proto.Clone(batch).(*pb.Batch)
+				if b.Parameters == nil {
+					b.Parameters = &pb.BatchParameters{}
+				}
+				b.Parameters.Consistency = consistency
+			}
+			return s.speculate(ctx, KindBatch, idempotent, func(ctx context.Context) (*pb.Response, error) {
+				return s.client.ExecuteBatch(ctx, b)
+			})
+		})
+	endSpan(resp, err)
+	finished(resp, err)
+	logged(resp, err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %v", err)
+		return nil, fmt.Errorf("failed to execute batch: %w", err)
 	}
+	s.handleBatchWarnings(ctx, batch, resp)
 
 	return resp, nil
 }
 
+func (s *StargateClient) callOptions(opts []CallOption) *callOptions {
+	o := &callOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
 // WithTimeout returns a StargateClientOption which sets the context timeout for
 // client requests.
 func WithTimeout(timeout time.Duration) StargateClientOption {
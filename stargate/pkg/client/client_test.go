@@ -369,7 +369,7 @@ func TestExecuteQuery_FullCRUD(t *testing.T) {
 
 	varint, err := ToVarInt(result.Rows[0].Values[22])
 	require.NoError(t, err)
-	assert.Equal(t, uint64(4), varint)
+	assert.Equal(t, big.NewInt(4), varint)
 
 	// update table
 	query = &pb.Query{
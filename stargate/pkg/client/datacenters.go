@@ -0,0 +1,112 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// Datacenter is a named group of endpoints in the same datacenter.
+type Datacenter struct {
+	Name      string
+	Endpoints []string
+}
+
+// WithDatacenters returns a DialOption which groups endpoints by datacenter.
+// Requests are sent to the local datacenter, and to the others in the order
+// given only when no endpoint of the preceding ones is available. Requests at
+// a LOCAL_* consistency level never leave the local datacenter unless
+// WithLocalConsistencyFailover is also given.
+func WithDatacenters(local string, datacenters ...Datacenter) DialOption {
+	return func(c *Config) {
+		c.LocalDatacenter = local
+		c.Datacenters = append(c.Datacenters, datacenters...)
+	}
+}
+
+// WithLocalConsistencyFailover returns a DialOption which allows requests at a
+// LOCAL_* consistency level to fail over to a remote datacenter.
+func WithLocalConsistencyFailover() DialOption {
+	return func(c *Config) {
+		c.LocalConsistencyFailover = true
+	}
+}
+
+func (c Config) validateDatacenters() error {
+	if len(c.Datacenters) == 0 {
+		return nil
+	}
+	if c.Endpoint != "" || len(c.Endpoints) > 0 {
+		return errors.New("cannot use both endpoints and datacenters")
+	}
+
+	seen := make(map[string]bool)
+	endpoints := make(map[string]bool)
+	for _, dc := range c.Datacenters {
+		switch {
+		case dc.Name == "":
+			return errors.New("datacenter name is required")
+		case seen[dc.Name]:
+			return fmt.Errorf("datacenter %s is listed more than once", dc.Name)
+		case len(dc.Endpoints) == 0:
+			return fmt.Errorf("datacenter %s has no endpoints", dc.Name)
+		}
+		seen[dc.Name] = true
+
+		for _, endpoint := range dc.Endpoints {
+			if endpoints[endpoint] {
+				return fmt.Errorf("endpoint %s is listed more than once", endpoint)
+			}
+			endpoints[endpoint] = true
+		}
+	}
+	if c.LocalDatacenter != "" && !seen[c.LocalDatacenter] {
+		return fmt.Errorf("local datacenter %s is not listed", c.LocalDatacenter)
+	}
+	return nil
+}
+
+// datacenterOrder returns the datacenters with the local one first, followed
+// by the others in the order given.
+func (c Config) datacenterOrder() []Datacenter {
+	local := c.LocalDatacenter
+	if local == "" {
+		local = c.Datacenters[0].Name
+	}
+
+	ordered := make([]Datacenter, 0, len(c.Datacenters))
+	for _, dc := range c.Datacenters {
+		if dc.Name == local {
+			ordered = append(ordered, dc)
+		}
+	}
+	for _, dc := range c.Datacenters {
+		if dc.Name != local {
+			ordered = append(ordered, dc)
+		}
+	}
+	return ordered
+}
+
+// isLocalConsistency reports whether a request must be served by the local
+// datacenter. Requests without a consistency level use Stargate's default of
+// LOCAL_QUORUM.
+func isLocalConsistency(params interface {
+	GetConsistency() *pb.ConsistencyValue
+	GetSerialConsistency() *pb.ConsistencyValue
+}) bool {
+	consistency := params.GetConsistency()
+	if consistency == nil {
+		return true
+	}
+	return isLocal(consistency.GetValue()) || isLocal(params.GetSerialConsistency().GetValue())
+}
+
+func isLocal(consistency pb.Consistency) bool {
+	switch consistency {
+	case pb.Consistency_LOCAL_ONE, pb.Consistency_LOCAL_QUORUM, pb.Consistency_LOCAL_SERIAL:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// threeDatacenters returns a pool of endpoints a to e spread over three
+// datacenters, with dc2 local and dc3 preferred over dc1.
+func threeDatacenters(config Config) (*endpointPool, []*switchableStargate) {
+	var fakes []*switchableStargate
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		fakes = append(fakes, newSwitchableStargate(name))
+	}
+	config.FailureThreshold = 1
+	config.LocalDatacenter = "dc2"
+	config.Datacenters = []Datacenter{
+		{Name: "dc3", Endpoints: []string{"d:8090", "e:8090"}},
+		{Name: "dc2", Endpoints: []string{"b:8090", "c:8090"}},
+		{Name: "dc1", Endpoints: []string{"a:8090"}},
+	}
+	return newTestPool(config, fakes...), fakes
+}
+
+func queryAt(consistency pb.Consistency) *pb.Query {
+	return &pb.Query{Parameters: &pb.QueryParameters{Consistency: &pb.ConsistencyValue{Value: consistency}}}
+}
+
+func answeredAt(t *testing.T, p *endpointPool, consistency pb.Consistency) string {
+	t.Helper()
+	resp, err := p.ExecuteQuery(context.Background(), queryAt(consistency))
+	require.NoError(t, err)
+	return resp.GetResultSet().GetRows()[0].GetValues()[0].GetString_()
+}
+
+func TestDatacenters_PrefersLocal(t *testing.T) {
+	p, _ := threeDatacenters(Config{})
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, answeredAt(t, p, pb.Consistency_QUORUM))
+	}
+	assert.Equal(t, []string{"b", "c", "b", "c"}, got)
+
+	health := p.health()
+	assert.Equal(t, "dc1", health[0].Datacenter)
+	assert.Equal(t, "dc2", health[1].Datacenter)
+	assert.Equal(t, "dc3", health[3].Datacenter)
+}
+
+func TestDatacenters_RemoteFallbackInOrder(t *testing.T) {
+	p, fakes := threeDatacenters(Config{})
+	fakes[1].setDown(true)
+	fakes[2].setDown(true)
+
+	// Take the local endpoints out of rotation.
+	for i := 0; i < 2; i++ {
+		_, _ = p.ExecuteQuery(context.Background(), queryAt(pb.Consistency_QUORUM))
+	}
+
+	assert.Equal(t, "d", answeredAt(t, p, pb.Consistency_QUORUM))
+	assert.Equal(t, "e", answeredAt(t, p, pb.Consistency_ONE))
+
+	fakes[3].setDown(true)
+	fakes[4].setDown(true)
+	for i := 0; i < 2; i++ {
+		_, _ = p.ExecuteQuery(context.Background(), queryAt(pb.Consistency_QUORUM))
+	}
+	assert.Equal(t, "a", answeredAt(t, p, pb.Consistency_QUORUM))
+}
+
+func TestDatacenters_LocalConsistencyStaysLocal(t *testing.T) {
+	p, fakes := threeDatacenters(Config{})
+	fakes[1].setDown(true)
+	fakes[2].setDown(true)
+	for i := 0; i < 2; i++ {
+		_, _ = p.ExecuteQuery(context.Background(), queryAt(pb.Consistency_QUORUM))
+	}
+
+	tests := []struct {
+		name  string
+		query *pb.Query
+	}{
+		{"local quorum", queryAt(pb.Consistency_LOCAL_QUORUM)},
+		{"local one", queryAt(pb.Consistency_LOCAL_ONE)},
+		{"server default", &pb.Query{}},
+		{"local serial", &pb.Query{Parameters: &pb.QueryParameters{
+			Consistency:       &pb.ConsistencyValue{Value: pb.Consistency_QUORUM},
+			SerialConsistency: &pb.ConsistencyValue{Value: pb.Consistency_LOCAL_SERIAL},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.ExecuteQuery(context.Background(), tt.query)
+			require.Error(t, err)
+			assert.Equal(t, codes.Unavailable, StatusCode(err))
+			assert.Contains(t, err.Error(), "no endpoint in local datacenter dc2 is available")
+		})
+	}
+
+	_, err := p.ExecuteBatch(context.Background(), &pb.Batch{Parameters: &pb.BatchParameters{
+		Consistency: &pb.ConsistencyValue{Value: pb.Consistency_LOCAL_QUORUM},
+	}})
+	require.Error(t, err)
+	assert.Equal(t, 0, fakes[3].hits+fakes[4].hits)
+}
+
+func TestDatacenters_LocalConsistencyFailover(t *testing.T) {
+	p, fakes := threeDatacenters(Config{LocalConsistencyFailover: true})
+	fakes[1].setDown(true)
+	fakes[2].setDown(true)
+	for i := 0; i < 2; i++ {
+		_, _ = p.ExecuteQuery(context.Background(), queryAt(pb.Consistency_QUORUM))
+	}
+
+	assert.Equal(t, "d", answeredAt(t, p, pb.Consistency_LOCAL_QUORUM))
+}
+
+func TestDial_InvalidDatacenters(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		err    string
+	}{
+		{
+			"endpoints and datacenters",
+			Config{Endpoint: "a:8090", Datacenters: []Datacenter{{Name: "dc1", Endpoints: []string{"b:8090"}}}},
+			"cannot use both endpoints and datacenters",
+		},
+		{
+			"no endpoints",
+			Config{Datacenters: []Datacenter{{Name: "dc1", Endpoints: []string{"a:8090"}}, {Name: "dc2"}}},
+			"datacenter dc2 has no endpoints",
+		},
+		{
+			"duplicate endpoint",
+			Config{Datacenters: []Datacenter{
+				{Name: "dc1", Endpoints: []string{"a:8090"}},
+				{Name: "dc2", Endpoints: []string{"a:8090"}},
+			}},
+			"endpoint a:8090 is listed more than once",
+		},
+		{
+			"unknown local datacenter",
+			Config{LocalDatacenter: "dc2", Datacenters: []Datacenter{{Name: "dc1", Endpoints: []string{"a:8090"}}}},
+			"local datacenter dc2 is not listed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Insecure = true
+			_, err := Dial(context.Background(), tt.config)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,515 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"gopkg.in/inf.v0"
+)
+
+// Unmarshaler is implemented by types that can decode themselves from a CQL
+// value. It is consulted by Rows.Scan before any built-in conversion.
+type Unmarshaler interface {
+	UnmarshalCQL(spec *pb.TypeSpec, value *pb.Value) error
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+	durationType    = reflect.TypeOf(time.Duration(0))
+	uuidType        = reflect.TypeOf(uuid.UUID{})
+	bigIntType      = reflect.TypeOf(big.Int{})
+	decType         = reflect.TypeOf(inf.Dec{})
+	ipType          = reflect.TypeOf(net.IP{})
+)
+
+// ToGo decodes val into the Go value pointed to by dest, using spec to
+// interpret the value where the Go type alone is ambiguous. A null value sets
+// dest to its zero value, so pointer destinations can be used for nullable
+// columns.
+func ToGo(val *pb.Value, spec *pb.TypeSpec, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("destination must be a non-nil pointer, got %T", dest)
+	}
+
+	return decodeInto(val, spec, rv.Elem())
+}
+
+func isNull(val *pb.Value) bool {
+	switch val.GetInner().(type) {
+	case nil, *pb.Value_Null_, *pb.Value_Unset_:
+		return true
+	}
+	return false
+}
+
+func decodeInto(val *pb.Value, spec *pb.TypeSpec, dst reflect.Value) error {
+	if dst.CanAddr() && dst.Addr().Type().Implements(unmarshalerType) {
+		return dst.Addr().Interface().(Unmarshaler).UnmarshalCQL(spec, val)
+	}
+
+	if isNull(val) {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	switch dst.Type() {
+	case timeType:
+		return decodeTime(val, spec, dst)
+	case durationType:
+		if inner, ok := val.GetInner().(*pb.Value_Time); ok {
+			dst.SetInt(int64(inner.Time))
+			return nil
+		}
+		return cannotDecode(val, dst)
+	case uuidType:
+		u, err := ToUUID(val)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(*u))
+		return nil
+	case bigIntType:
+		i, err := decodeBigInt(val)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(*i))
+		return nil
+	case decType:
+		d, err := ToDecimal(val)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(*d))
+		return nil
+	case ipType:
+		ip, err := ToInet(val)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(net.IP(ip)))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return decodeInto(val, spec, dst.Elem())
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return cannotDecode(val, dst)
+		}
+		v, err := decodeAny(val, spec)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+		} else {
+			dst.Set(reflect.ValueOf(v))
+		}
+		return nil
+	case reflect.String:
+		return decodeString(val, dst)
+	case reflect.Bool:
+		b, err := ToBoolean(val)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return decodeInt(val, dst)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return decodeUint(val, dst)
+	case reflect.Float32, reflect.Float64:
+		return decodeFloat(val, dst)
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := ToBlob(val)
+			if err != nil {
+				return err
+			}
+			dst.SetBytes(b)
+			return nil
+		}
+		return decodeSequence(val, spec, dst)
+	case reflect.Array:
+		return decodeSequence(val, spec, dst)
+	case reflect.Map:
+		return decodeMap(val, spec, dst)
+	case reflect.Struct:
+		return decodeStruct(val, spec, dst)
+	}
+
+	return cannotDecode(val, dst)
+}
+
+func decodeTime(val *pb.Value, spec *pb.TypeSpec, dst reflect.Value) error {
+	var t time.Time
+	switch inner := val.GetInner().(type) {
+	case *pb.Value_Int:
+		if !isBasic(spec, pb.TypeSpec_TIMESTAMP) {
+			return cannotDecode(val, dst)
+		}
+		t = time.UnixMilli(inner.Int).UTC()
+	case *pb.Value_Date:
+		days := int64(inner.Date) - epochDateOffset
+		t = time.Unix(days*86400, 0).UTC()
+	default:
+		return cannotDecode(val, dst)
+	}
+
+	dst.Set(reflect.ValueOf(t))
+	return nil
+}
+
+func decodeBigInt(val *pb.Value) (*big.Int, error) {
+	if inner, ok := val.GetInner().(*pb.Value_Int); ok {
+		return big.NewInt(inner.Int), nil
+	}
+	return ToVarInt(val)
+}
+
+func decodeString(val *pb.Value, dst reflect.Value) error {
+	switch inner := val.GetInner().(type) {
+	case *pb.Value_String_:
+		dst.SetString(inner.String_)
+	case *pb.Value_Uuid:
+		u, err := ToUUID(val)
+		if err != nil {
+			return err
+		}
+		dst.SetString(u.String())
+	case *pb.Value_Inet:
+		dst.SetString(net.IP(inner.Inet.GetValue()).String())
+	case *pb.Value_Varint:
+		i, err := ToVarInt(val)
+		if err != nil {
+			return err
+		}
+		dst.SetString(i.String())
+	case *pb.Value_Decimal:
+		d, err := ToDecimal(val)
+		if err != nil {
+			return err
+		}
+		dst.SetString(d.String())
+	default:
+		return cannotDecode(val, dst)
+	}
+
+	return nil
+}
+
+func decodeInt(val *pb.Value, dst reflect.Value) error {
+	var i int64
+	switch inner := val.GetInner().(type) {
+	case *pb.Value_Int:
+		i = inner.Int
+	case *pb.Value_Varint:
+		v, err := ToVarInt(val)
+		if err != nil {
+			return err
+		}
+		if !v.IsInt64() {
+			return fmt.Errorf("value %s overflows %s", v, dst.Type())
+		}
+		i = v.Int64()
+	case *pb.Value_Date:
+		i = int64(inner.Date)
+	case *pb.Value_Time:
+		i = int64(inner.Time)
+	default:
+		return cannotDecode(val, dst)
+	}
+
+	if dst.OverflowInt(i) {
+		return fmt.Errorf("value %d overflows %s", i, dst.Type())
+	}
+	dst.SetInt(i)
+	return nil
+}
+
+func decodeUint(val *pb.Value, dst reflect.Value) error {
+	var u uint64
+	switch inner := val.GetInner().(type) {
+	case *pb.Value_Int:
+		if inner.Int < 0 {
+			return fmt.Errorf("value %d overflows %s", inner.Int, dst.Type())
+		}
+		u = uint64(inner.Int)
+	case *pb.Value_Varint:
+		v, err := ToVarInt(val)
+		if err != nil {
+			return err
+		}
+		if !v.IsUint64() {
+			return fmt.Errorf("value %s overflows %s", v, dst.Type())
+		}
+		u = v.Uint64()
+	case *pb.Value_Date:
+		u = uint64(inner.Date)
+	case *pb.Value_Time:
+		u = inner.Time
+	default:
+		return cannotDecode(val, dst)
+	}
+
+	if dst.OverflowUint(u) {
+		return fmt.Errorf("value %d overflows %s", u, dst.Type())
+	}
+	dst.SetUint(u)
+	return nil
+}
+
+func decodeFloat(val *pb.Value, dst reflect.Value) error {
+	var f float64
+	switch inner := val.GetInner().(type) {
+	case *pb.Value_Float:
+		f = float64(inner.Float)
+	case *pb.Value_Double:
+		f = inner.Double
+	default:
+		return cannotDecode(val, dst)
+	}
+
+	if dst.OverflowFloat(f) {
+		return fmt.Errorf("value %v overflows %s", f, dst.Type())
+	}
+	dst.SetFloat(f)
+	return nil
+}
+
+func decodeSequence(val *pb.Value, spec *pb.TypeSpec, dst reflect.Value) error {
+	collection := val.GetCollection()
+	if collection == nil {
+		return cannotDecode(val, dst)
+	}
+	elements := collection.GetElements()
+
+	var specs func(i int) *pb.TypeSpec
+	switch s := spec.GetSpec().(type) {
+	case nil:
+		specs = func(int) *pb.TypeSpec { return nil }
+	case *pb.TypeSpec_List_:
+		specs = func(int) *pb.TypeSpec { return s.List.GetElement() }
+	case *pb.TypeSpec_Set_:
+		specs = func(int) *pb.TypeSpec { return s.Set.GetElement() }
+	case *pb.TypeSpec_Tuple_:
+		specs = func(i int) *pb.TypeSpec {
+			if i < len(s.Tuple.GetElements()) {
+				return s.Tuple.GetElements()[i]
+			}
+			return nil
+		}
+	default:
+		return cannotDecode(val, dst)
+	}
+
+	if dst.Kind() == reflect.Array {
+		if dst.Len() != len(elements) {
+			return fmt.Errorf("cannot decode %d elements into %s", len(elements), dst.Type())
+		}
+	} else {
+		dst.Set(reflect.MakeSlice(dst.Type(), len(elements), len(elements)))
+	}
+
+	for i, element := range elements {
+		if err := decodeInto(element, specs(i), dst.Index(i)); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func decodeMap(val *pb.Value, spec *pb.TypeSpec, dst reflect.Value) error {
+	if udt := val.GetUdt(); udt != nil {
+		if dst.Type().Key().Kind() != reflect.String {
+			return cannotDecode(val, dst)
+		}
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), len(udt.GetFields())))
+		for name, field := range udt.GetFields() {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := decodeInto(field, spec.GetUdt().GetFields()[name], elem); err != nil {
+				return fmt.Errorf("field %q: %w", name, err)
+			}
+			dst.SetMapIndex(reflect.ValueOf(name).Convert(dst.Type().Key()), elem)
+		}
+		return nil
+	}
+
+	collection := val.GetCollection()
+	if collection == nil {
+		return cannotDecode(val, dst)
+	}
+	elements := collection.GetElements()
+
+	if set := spec.GetSet(); set != nil {
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), len(elements)))
+		present := reflect.New(dst.Type().Elem()).Elem()
+		if present.Kind() == reflect.Bool {
+			present.SetBool(true)
+		}
+		for i, element := range elements {
+			key := reflect.New(dst.Type().Key()).Elem()
+			if err := decodeInto(element, set.GetElement(), key); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+			dst.SetMapIndex(key, present)
+		}
+		return nil
+	}
+
+	if spec != nil && spec.GetMap() == nil {
+		return cannotDecode(val, dst)
+	}
+	if len(elements)%2 != 0 {
+		return errors.New("map has an odd number of elements")
+	}
+
+	dst.Set(reflect.MakeMapWithSize(dst.Type(), len(elements)/2))
+	for i := 0; i < len(elements); i += 2 {
+		key := reflect.New(dst.Type().Key()).Elem()
+		if err := decodeInto(elements[i], spec.GetMap().GetKey(), key); err != nil {
+			return fmt.Errorf("map key: %w", err)
+		}
+		elem := reflect.New(dst.Type().Elem()).Elem()
+		if err := decodeInto(elements[i+1], spec.GetMap().GetValue(), elem); err != nil {
+			return fmt.Errorf("map value: %w", err)
+		}
+		dst.SetMapIndex(key, elem)
+	}
+
+	return nil
+}
+
+func decodeStruct(val *pb.Value, spec *pb.TypeSpec, dst reflect.Value) error {
+	udt := val.GetUdt()
+	if udt == nil {
+		return cannotDecode(val, dst)
+	}
+
+	info := cachedStructInfo(dst.Type())
+	for name, value := range udt.GetFields() {
+		field, ok := info.byName[name]
+		if !ok {
+			continue
+		}
+		if err := decodeInto(value, spec.GetUdt().GetFields()[name], fieldByIndex(dst, field.index)); err != nil {
+			return fmt.Errorf("field %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// decodeAny decodes val into its natural Go representation. When spec is
+// available the conversion matches the ToXxx functions, otherwise the type is
+// inferred from the value itself.
+func decodeAny(val *pb.Value, spec *pb.TypeSpec) (interface{}, error) {
+	if isNull(val) {
+		return nil, nil
+	}
+	if spec != nil {
+		return translateType(val, spec)
+	}
+
+	switch inner := val.GetInner().(type) {
+	case *pb.Value_Int:
+		return inner.Int, nil
+	case *pb.Value_Float:
+		return inner.Float, nil
+	case *pb.Value_Double:
+		return inner.Double, nil
+	case *pb.Value_Boolean:
+		return inner.Boolean, nil
+	case *pb.Value_String_:
+		return inner.String_, nil
+	case *pb.Value_Bytes:
+		return inner.Bytes, nil
+	case *pb.Value_Inet:
+		return ToInet(val)
+	case *pb.Value_Uuid:
+		return ToUUID(val)
+	case *pb.Value_Date:
+		return inner.Date, nil
+	case *pb.Value_Time:
+		return inner.Time, nil
+	case *pb.Value_Varint:
+		return ToVarInt(val)
+	case *pb.Value_Decimal:
+		return ToDecimal(val)
+	case *pb.Value_Collection:
+		elements := make([]interface{}, 0, len(inner.Collection.GetElements()))
+		for _, element := range inner.Collection.GetElements() {
+			v, err := decodeAny(element, nil)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, v)
+		}
+		return elements, nil
+	case *pb.Value_Udt:
+		fields := make(map[string]interface{}, len(inner.Udt.GetFields()))
+		for name, field := range inner.Udt.GetFields() {
+			v, err := decodeAny(field, nil)
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = v
+		}
+		return fields, nil
+	}
+
+	return nil, errors.New("unsupported type")
+}
+
+func cannotDecode(val *pb.Value, dst reflect.Value) error {
+	return fmt.Errorf("cannot decode %s into %s", innerName(val), dst.Type())
+}
+
+func innerName(val *pb.Value) string {
+	switch val.GetInner().(type) {
+	case *pb.Value_Int:
+		return "int"
+	case *pb.Value_Float:
+		return "float"
+	case *pb.Value_Double:
+		return "double"
+	case *pb.Value_Boolean:
+		return "boolean"
+	case *pb.Value_String_:
+		return "string"
+	case *pb.Value_Bytes:
+		return "bytes"
+	case *pb.Value_Inet:
+		return "inet"
+	case *pb.Value_Uuid:
+		return "uuid"
+	case *pb.Value_Date:
+		return "date"
+	case *pb.Value_Time:
+		return "time"
+	case *pb.Value_Collection:
+		return "collection"
+	case *pb.Value_Udt:
+		return "udt"
+	case *pb.Value_Varint:
+		return "varint"
+	case *pb.Value_Decimal:
+		return "decimal"
+	}
+	return "null"
+}
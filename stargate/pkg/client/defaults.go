@@ -0,0 +1,89 @@
+package client
+
+import (
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// defaults holds parameters applied to queries and batches that do not set
+// their own.
+type defaults struct {
+	keyspace    string
+	consistency *pb.Consistency
+	pageSize    int32
+}
+
+// WithDefaultKeyspace returns a StargateClientOption which sets the keyspace
+// used by queries and batches that do not specify one.
+func WithDefaultKeyspace(keyspace string) StargateClientOption {
+	return func(c *StargateClient) {
+		c.defaults.keyspace = keyspace
+	}
+}
+
+// WithDefaultConsistency returns a StargateClientOption which sets the
+// consistency level used by queries and batches that do not specify one.
+func WithDefaultConsistency(consistency pb.Consistency) StargateClientOption {
+	return func(c *StargateClient) {
+		c.defaults.consistency = &consistency
+	}
+}
+
+// WithDefaultPageSize returns a StargateClientOption which sets the page size
+// used by queries that do not specify one.
+func WithDefaultPageSize(pageSize int32) StargateClientOption {
+	return func(c *StargateClient) {
+		c.defaults.pageSize = pageSize
+	}
+}
+
+// applyToQuery returns query with the defaults filled in, cloning it rather
+// than modifying the caller's query.
+func (d defaults) applyToQuery(query *pb.Query) *pb.Query {
+	params := query.GetParameters()
+	missingKeyspace := d.keyspace != "" && params.GetKeyspace() == nil
+	missingConsistency := d.consistency != nil && params.GetConsistency() == nil
+	missingPageSize := d.pageSize > 0 && params.GetPageSize() == nil
+	if !missingKeyspace && !missingConsistency && !missingPageSize {
+		return query
+	}
+
+	query = proto.Clone(query).(*pb.Query)
+	if query.Parameters == nil {
+		query.Parameters = &pb.QueryParameters{}
+	}
+	if missingKeyspace {
+		query.Parameters.Keyspace = wrapperspb.String(d.keyspace)
+	}
+	if missingConsistency {
+		query.Parameters.Consistency = &pb.ConsistencyValue{Value: *d.consistency}
+	}
+	if missingPageSize {
+		query.Parameters.PageSize = wrapperspb.Int32(d.pageSize)
+	}
+	return query
+}
+
+// applyToBatch returns batch with the defaults filled in, cloning it rather
+// than modifying the caller's batch.
+func (d defaults) applyToBatch(batch *pb.Batch) *pb.Batch {
+	params := batch.GetParameters()
+	missingKeyspace := d.keyspace != "" && params.GetKeyspace() == nil
+	missingConsistency := d.consistency != nil && params.GetConsistency() == nil
+	if !missingKeyspace && !missingConsistency {
+		return batch
+	}
+
+	batch = proto.Clone(batch).(*pb.Batch)
+	if batch.Parameters == nil {
+		batch.Parameters = &pb.BatchParameters{}
+	}
+	if missingKeyspace {
+		batch.Parameters.Keyspace = wrapperspb.String(d.keyspace)
+	}
+	if missingConsistency {
+		batch.Parameters.Consistency = &pb.ConsistencyValue{Value: *d.consistency}
+	}
+	return batch
+}
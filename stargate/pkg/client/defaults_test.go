@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestDefaults(t *testing.T) {
+	var received *pb.Query
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+			received = query
+			return &pb.Response{}, nil
+		},
+	}, WithDefaultKeyspace("ks"), WithDefaultConsistency(pb.Consistency_LOCAL_ONE), WithDefaultPageSize(50))
+
+	query := &pb.Query{Cql: "SELECT * FROM tbl"}
+	_, err := s.ExecuteQuery(query)
+	require.NoError(t, err)
+	assert.Nil(t, query.Parameters, "the caller's query is not modified")
+	assert.Equal(t, "ks", received.Parameters.GetKeyspace().GetValue())
+	assert.Equal(t, pb.Consistency_LOCAL_ONE, received.Parameters.GetConsistency().GetValue())
+	assert.Equal(t, int32(50), received.Parameters.GetPageSize().GetValue())
+
+	query = &pb.Query{Cql: "SELECT * FROM tbl", Parameters: &pb.QueryParameters{
+		Keyspace:    wrapperspb.String("other"),
+		Consistency: &pb.ConsistencyValue{Value: pb.Consistency_ALL},
+		PageSize:    wrapperspb.Int32(10),
+	}}
+	_, err = s.ExecuteQuery(query)
+	require.NoError(t, err)
+	assert.Same(t, query, received, "queries setting every parameter are sent as is")
+}
+
+func TestDefaults_Batch(t *testing.T) {
+	var received *pb.Batch
+	s := newFakeClient(&fakeStargate{
+		executeBatch: func(ctx context.Context, batch *pb.Batch) (*pb.Response, error) {
+			received = batch
+			return &pb.Response{}, nil
+		},
+	}, WithDefaultKeyspace("ks"), WithDefaultConsistency(pb.Consistency_QUORUM))
+
+	_, err := s.ExecuteBatch(&pb.Batch{Queries: []*pb.BatchQuery{{Cql: "INSERT INTO tbl (k) VALUES (1)"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "ks", received.Parameters.GetKeyspace().GetValue())
+	assert.Equal(t, pb.Consistency_QUORUM, received.Parameters.GetConsistency().GetValue())
+}
@@ -0,0 +1,376 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/auth"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Config describes how to connect to Stargate.
+type Config struct {
+	// Endpoint is the address of Stargate's gRPC port, such as
+	// localhost:8090.
+	Endpoint string
+	// Endpoints are further addresses of Stargate gateways. Requests are
+	// spread across Endpoint and Endpoints according to LoadBalancing.
+	Endpoints []string
+	// LoadBalancing selects how requests are spread across several
+	// endpoints.
+	LoadBalancing LoadBalancingPolicy
+	// FailureThreshold is the number of consecutive requests that must fail
+	// to reach an endpoint before it is taken out of rotation, 3 if zero.
+	FailureThreshold int
+	// MinProbeBackoff and MaxProbeBackoff bound the exponential back-off
+	// between probe requests to an endpoint that is down, 1s and 1m if zero.
+	MinProbeBackoff time.Duration
+	MaxProbeBackoff time.Duration
+	// Datacenters groups endpoints by datacenter, in place of Endpoint and
+	// Endpoints. Requests are sent to LocalDatacenter, or the first
+	// datacenter if it is empty, and to the others in order only when no
+	// endpoint of the preceding ones is available.
+	Datacenters     []Datacenter
+	LocalDatacenter string
+	// LocalConsistencyFailover allows requests at a LOCAL_* consistency level
+	// to fail over to a remote datacenter.
+	LocalConsistencyFailover bool
+
+	// TLS is the TLS configuration used to connect. If it is nil and
+	// Insecure is false, TLS is used with the system's root certificates.
+	TLS *tls.Config
+	// Insecure disables transport security. Credentials are then created
+	// with their Unsafe variants.
+	Insecure bool
+
+	// Credentials supplies the token sent with every request. If it is nil,
+	// a provider is created from Token, or from AuthURL, Username and
+	// Password. If it caches its token, calls rejected with
+	// codes.Unauthenticated are retried once with a new token.
+	Credentials credentials.PerRPCCredentials
+	// Token is a static token.
+	Token string
+	// AuthURL is the URL of Stargate's auth API, such as
+	// http://localhost:8081/v1/auth, used to exchange Username and Password
+	// for a token.
+	AuthURL  string
+	Username string
+	Password string
+
+	// Keepalive sets the gRPC keepalive parameters. Pings are only sent if
+	// Keepalive.Time is set, and should not be more frequent than the server
+	// permits.
+	Keepalive keepalive.ClientParameters
+	// MaxRecvMsgSize and MaxSendMsgSize set the maximum message sizes in
+	// bytes, if not zero.
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+	// ServiceConfig is the default gRPC service config in JSON.
+	ServiceConfig string
+	// WaitForReady makes calls wait for the connection to become ready
+	// rather than failing with codes.Unavailable while it cannot be
+	// established. Calls to an unreachable gateway then only fail once their
+	// deadline is exceeded, which also delays taking the endpoint out of
+	// rotation when there are several.
+	WaitForReady bool
+	// Block makes Dial wait until the connection is established, subject to
+	// the deadline of its context.
+	Block bool
+
+	// ClientOptions are applied to the StargateClient.
+	ClientOptions []StargateClientOption
+	// DialOptions are appended to the options used to dial the connection.
+	DialOptions []grpc.DialOption
+}
+
+// DialOption is an option for NewStargateClient.
+type DialOption func(*Config)
+
+// WithEndpoints returns a DialOption which adds further endpoints to spread
+// requests across.
+func WithEndpoints(endpoints ...string) DialOption {
+	return func(c *Config) {
+		c.Endpoints = append(c.Endpoints, endpoints...)
+	}
+}
+
+// WithLoadBalancing returns a DialOption which sets how requests are spread
+// across several endpoints.
+func WithLoadBalancing(policy LoadBalancingPolicy) DialOption {
+	return func(c *Config) {
+		c.LoadBalancing = policy
+	}
+}
+
+// WithFailover returns a DialOption which takes an endpoint out of rotation
+// after failureThreshold consecutive failures, probing it again after minBackoff
+// doubling up to maxBackoff.
+func WithFailover(failureThreshold int, minBackoff, maxBackoff time.Duration) DialOption {
+	return func(c *Config) {
+		c.FailureThreshold = failureThreshold
+		c.MinProbeBackoff, c.MaxProbeBackoff = minBackoff, maxBackoff
+	}
+}
+
+// WithTLS returns a DialOption which connects using config.
+func WithTLS(config *tls.Config) DialOption {
+	return func(c *Config) {
+		c.TLS = config
+	}
+}
+
+// WithInsecure returns a DialOption which disables transport security.
+func WithInsecure() DialOption {
+	return func(c *Config) {
+		c.Insecure = true
+	}
+}
+
+// WithCredentials returns a DialOption which sends the token supplied by creds
+// with every request.
+func WithCredentials(creds credentials.PerRPCCredentials) DialOption {
+	return func(c *Config) {
+		c.Credentials = creds
+	}
+}
+
+// WithToken returns a DialOption which sends token with every request.
+func WithToken(token string) DialOption {
+	return func(c *Config) {
+		c.Token = token
+	}
+}
+
+// WithTableBasedAuth returns a DialOption which exchanges username and password
+// for a token using the auth API at authURL.
+func WithTableBasedAuth(authURL, username, password string) DialOption {
+	return func(c *Config) {
+		c.AuthURL, c.Username, c.Password = authURL, username, password
+	}
+}
+
+// WithKeepalive returns a DialOption which sets the gRPC keepalive parameters.
+func WithKeepalive(params keepalive.ClientParameters) DialOption {
+	return func(c *Config) {
+		c.Keepalive = params
+	}
+}
+
+// WithMaxMessageSize returns a DialOption which sets the maximum size in bytes
+// of messages received and sent.
+func WithMaxMessageSize(recv, send int) DialOption {
+	return func(c *Config) {
+		c.MaxRecvMsgSize, c.MaxSendMsgSize = recv, send
+	}
+}
+
+// WithServiceConfig returns a DialOption which sets the default gRPC service
+// config.
+func WithServiceConfig(serviceConfig string) DialOption {
+	return func(c *Config) {
+		c.ServiceConfig = serviceConfig
+	}
+}
+
+// WithWaitForReady returns a DialOption which makes calls wait for the
+// connection to become ready, see Config.WaitForReady.
+func WithWaitForReady() DialOption {
+	return func(c *Config) {
+		c.WaitForReady = true
+	}
+}
+
+// WithBlock returns a DialOption which waits for the connection to be
+// established.
+func WithBlock() DialOption {
+	return func(c *Config) {
+		c.Block = true
+	}
+}
+
+// WithClientOptions returns a DialOption which applies opts to the
+// StargateClient.
+func WithClientOptions(opts ...StargateClientOption) DialOption {
+	return func(c *Config) {
+		c.ClientOptions = append(c.ClientOptions, opts...)
+	}
+}
+
+// WithDialOptions returns a DialOption which adds gRPC dial options.
+func WithDialOptions(opts ...grpc.DialOption) DialOption {
+	return func(c *Config) {
+		c.DialOptions = append(c.DialOptions, opts...)
+	}
+}
+
+// NewStargateClient connects to the Stargate gRPC endpoint and creates a
+// StargateClient that owns the connection, see Dial.
+func NewStargateClient(ctx context.Context, endpoint string, opts ...DialOption) (*StargateClient, error) {
+	config := Config{Endpoint: endpoint}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return Dial(ctx, config)
+}
+
+// Dial connects to Stargate as described by config and creates a
+// StargateClient that owns the connections. The connections are closed by
+// calling Close on the client.
+//
+// When several endpoints are given, each request is sent to one of them, and
+// an endpoint that repeatedly cannot be reached is taken out of rotation
+// until it recovers. Requests that fail are not resent to another endpoint
+// unless a RetryPolicy retries them.
+func Dial(ctx context.Context, config Config) (*StargateClient, error) {
+	dialOpts, err := config.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := config.addresses()
+	var conns []*grpc.ClientConn
+	closeAll := func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}
+	for _, address := range addresses {
+		conn, err := grpc.DialContext(ctx, address, dialOpts...)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to dial %s: %w", address, err)
+		}
+		conns = append(conns, conn)
+	}
+
+	var sc *StargateClient
+	if len(conns) == 1 {
+		sc, err = NewStargateClientWithConn(conns[0], config.ClientOptions...)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+	} else {
+		clients := make([]pb.StargateClient, len(conns))
+		for i, conn := range conns {
+			clients[i] = pb.NewStargateClient(conn)
+		}
+		sc = newStargateClient(newEndpointPool(addresses, clients, config), config.ClientOptions...)
+	}
+	sc.conns = conns
+	return sc, nil
+}
+
+func (c Config) addresses() []string {
+	if len(c.Datacenters) > 0 {
+		var addresses []string
+		for _, dc := range c.Datacenters {
+			addresses = append(addresses, dc.Endpoints...)
+		}
+		return addresses
+	}
+	if c.Endpoint == "" {
+		return c.Endpoints
+	}
+	return append([]string{c.Endpoint}, c.Endpoints...)
+}
+
+func (c Config) dialOptions() ([]grpc.DialOption, error) {
+	if len(c.addresses()) == 0 {
+		return nil, errors.New("endpoint is required")
+	}
+	if err := c.validateDatacenters(); err != nil {
+		return nil, err
+	}
+
+	var opts []grpc.DialOption
+	switch {
+	case c.Insecure && c.TLS != nil:
+		return nil, errors.New("cannot use both TLS and an insecure connection")
+	case c.Insecure:
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	case c.TLS != nil:
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(c.TLS)))
+	default:
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+	}
+
+	creds, err := c.credentials()
+	if err != nil {
+		return nil, err
+	}
+	if creds != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(creds))
+		if _, ok := creds.(auth.TokenInvalidator); ok {
+			opts = append(opts, grpc.WithChainUnaryInterceptor(auth.NewReauthInterceptor(creds)))
+		}
+	}
+
+	if c.Keepalive.Time > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(c.Keepalive))
+	}
+
+	var callOpts []grpc.CallOption
+	if c.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(c.MaxRecvMsgSize))
+	}
+	if c.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(c.MaxSendMsgSize))
+	}
+	if c.WaitForReady {
+		callOpts = append(callOpts, grpc.WaitForReady(true))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	if c.ServiceConfig != "" {
+		opts = append(opts, grpc.WithDefaultServiceConfig(c.ServiceConfig))
+	}
+
+	if c.Block {
+		opts = append(opts, grpc.WithBlock())
+	}
+
+	return append(opts, c.DialOptions...), nil
+}
+
+// credentials returns the provider described by the config, or nil if no
+// authentication is configured.
+func (c Config) credentials() (credentials.PerRPCCredentials, error) {
+	switch {
+	case c.Credentials != nil:
+		return c.Credentials, nil
+	case c.Token != "" && c.Insecure:
+		return auth.NewStaticTokenProviderUnsafe(c.Token), nil
+	case c.Token != "":
+		return auth.NewStaticTokenProvider(c.Token), nil
+	case c.Username != "" && c.AuthURL == "":
+		return nil, errors.New("auth URL is required for username and password authentication")
+	case c.Username != "" && c.Insecure:
+		return auth.NewTableBasedTokenProviderUnsafe(c.AuthURL, c.Username, c.Password), nil
+	case c.Username != "":
+		return auth.NewTableBasedTokenProvider(c.AuthURL, c.Username, c.Password), nil
+	}
+	return nil, nil
+}
+
+// Close closes the connections created by Dial or NewStargateClient. A
+// connection passed to NewStargateClientWithConn is left open, since it is
+// owned by the caller.
+func (s *StargateClient) Close() error {
+	var firstErr error
+	for _, conn := range s.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
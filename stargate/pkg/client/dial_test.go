@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenServer is a Stargate server that only accepts requests carrying one of
+// the accepted tokens.
+type tokenServer struct {
+	pb.UnimplementedStargateServer
+	accepted map[string]bool
+	tokens   []string
+}
+
+func (s *tokenServer) ExecuteQuery(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := ""
+	if values := md.Get("x-cassandra-token"); len(values) > 0 {
+		token = values[0]
+	}
+	s.tokens = append(s.tokens, token)
+	if !s.accepted[token] {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return &pb.Response{}, nil
+}
+
+func startTokenServer(t *testing.T, accepted ...string) (*tokenServer, string) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	s := &tokenServer{accepted: map[string]bool{}}
+	for _, token := range accepted {
+		s.accepted[token] = true
+	}
+	server := grpc.NewServer()
+	pb.RegisterStargateServer(server, s)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	return s, lis.Addr().String()
+}
+
+func TestNewStargateClient(t *testing.T) {
+	server, endpoint := startTokenServer(t, "token-1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := NewStargateClient(ctx, endpoint, WithInsecure(), WithToken("token-1"), WithBlock(),
+		WithMaxMessageSize(16<<20, 16<<20), WithClientOptions(WithTimeout(time.Second)))
+	require.NoError(t, err)
+
+	_, err = s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM system.local"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"token-1"}, server.tokens)
+	assert.Equal(t, time.Second, s.timeout)
+
+	require.NoError(t, s.Close())
+	_, err = s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM system.local"})
+	assert.Equal(t, codes.Canceled, StatusCode(err))
+}
+
+func TestDial_TableBasedAuthReauthenticates(t *testing.T) {
+	var issued int32
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&issued, 1) == 1 {
+			_, _ = w.Write([]byte(`{"authToken": "revoked"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"authToken": "token-2"}`))
+	}))
+	defer authServer.Close()
+
+	server, endpoint := startTokenServer(t, "token-2")
+	s, err := Dial(context.Background(), Config{
+		Endpoint: endpoint,
+		Insecure: true,
+		AuthURL:  authServer.URL,
+		Username: "cassandra",
+		Password: "cassandra",
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, err = s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM system.local"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"revoked", "token-2"}, server.tokens)
+}
+
+func TestDial_Unreachable(t *testing.T) {
+	s, err := NewStargateClient(context.Background(), "127.0.0.1:1", WithInsecure(),
+		WithClientOptions(WithTimeout(5*time.Second)))
+	require.NoError(t, err)
+	defer s.Close()
+
+	start := time.Now()
+	_, err = s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM system.local"})
+	assert.Equal(t, codes.Unavailable, StatusCode(err))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "fails without waiting for the deadline")
+}
+
+func TestDial_UnreachableWaitForReady(t *testing.T) {
+	s, err := NewStargateClient(context.Background(), "127.0.0.1:1", WithInsecure(), WithWaitForReady(),
+		WithClientOptions(WithTimeout(50*time.Millisecond)))
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, err = s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM system.local"})
+	assert.Equal(t, codes.DeadlineExceeded, StatusCode(err))
+}
+
+func TestDial_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		err    string
+	}{
+		{"no endpoint", Config{}, "endpoint is required"},
+		{"no auth url", Config{Endpoint: "localhost:8090", Username: "cassandra"}, "auth URL is required"},
+		{"tls and insecure", Config{Endpoint: "localhost:8090", Insecure: true, TLS: &tls.Config{}}, "cannot use both"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Dial(context.Background(), tt.config)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestClose_WithConn(t *testing.T) {
+	s := newFakeClient(&fakeStargate{})
+	assert.NoError(t, s.Close())
+}
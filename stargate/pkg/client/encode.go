@@ -0,0 +1,630 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"gopkg.in/inf.v0"
+)
+
+// epochDateOffset is the value CQL uses to represent 1970-01-01 in the date
+// type, which is encoded as an unsigned number of days centered on the epoch.
+const epochDateOffset = 1 << 31
+
+// NullValue returns a value representing a CQL null.
+func NullValue() *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Null_{Null: &pb.Value_Null{}}}
+}
+
+// UnsetValue returns a value that leaves the bound column untouched.
+func UnsetValue() *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Unset_{Unset: &pb.Value_Unset{}}}
+}
+
+func FromString(s string) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_String_{String_: s}}
+}
+
+func FromInt(i int64) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Int{Int: i}}
+}
+
+func FromBoolean(b bool) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Boolean{Boolean: b}}
+}
+
+func FromFloat(f float32) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Float{Float: f}}
+}
+
+func FromDouble(f float64) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Double{Double: f}}
+}
+
+func FromBlob(b []byte) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Bytes{Bytes: b}}
+}
+
+func FromUUID(u uuid.UUID) *pb.Value {
+	b := make([]byte, len(u))
+	copy(b, u[:])
+	return &pb.Value{Inner: &pb.Value_Uuid{Uuid: &pb.Uuid{Value: b}}}
+}
+
+func FromTimeUUID(u uuid.UUID) *pb.Value {
+	return FromUUID(u)
+}
+
+// FromInet encodes an IP address, using the 4 byte form for IPv4 addresses.
+func FromInet(ip net.IP) *pb.Value {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	return &pb.Value{Inner: &pb.Value_Inet{Inet: &pb.Inet{Value: []byte(ip)}}}
+}
+
+// FromVarInt encodes an arbitrary-precision integer as a CQL varint.
+func FromVarInt(i *big.Int) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Varint{Varint: &pb.Varint{Value: encodeVarint(i)}}}
+}
+
+// FromDecimal encodes an arbitrary-precision decimal as a CQL decimal.
+func FromDecimal(d *inf.Dec) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Decimal{Decimal: &pb.Decimal{
+		Scale: uint32(d.Scale()),
+		Value: encodeVarint(d.UnscaledBig()),
+	}}}
+}
+
+// FromTimestamp encodes t as a CQL timestamp with millisecond precision.
+func FromTimestamp(t time.Time) *pb.Value {
+	return FromInt(t.UnixMilli())
+}
+
+// FromDate encodes the calendar date of t, as seen in UTC, as a CQL date.
+func FromDate(t time.Time) *pb.Value {
+	days := t.Unix() / 86400
+	if t.Unix() < 0 && t.Unix()%86400 != 0 {
+		days--
+	}
+	return &pb.Value{Inner: &pb.Value_Date{Date: uint32(days + epochDateOffset)}}
+}
+
+// FromTime encodes d, the time elapsed since midnight, as a CQL time.
+func FromTime(d time.Duration) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Time{Time: uint64(d)}}
+}
+
+// FromCollection builds a list, set, tuple or map value from already encoded
+// elements. Maps are represented as alternating keys and values.
+func FromCollection(elements ...*pb.Value) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Collection{Collection: &pb.Collection{Elements: elements}}}
+}
+
+// FromUDT builds a user defined type value from already encoded fields.
+func FromUDT(fields map[string]*pb.Value) *pb.Value {
+	return &pb.Value{Inner: &pb.Value_Udt{Udt: &pb.UdtValue{Fields: fields}}}
+}
+
+// NewValues encodes each of the provided Go values with FromGo so they can be
+// used as positional bind parameters for a query.
+func NewValues(vals ...interface{}) (*pb.Values, error) {
+	values := make([]*pb.Value, 0, len(vals))
+	for i, val := range vals {
+		value, err := FromGo(val, nil)
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %w", i, err)
+		}
+		values = append(values, value)
+	}
+
+	return &pb.Values{Values: values}, nil
+}
+
+// FromGo converts a Go value into a pb.Value. When spec is nil the CQL type is
+// inferred from the Go type, otherwise the value is converted to match spec and
+// an error is returned if the two are incompatible. A nil value, nil pointer or
+// nil slice is encoded as null, and a *pb.Value is returned unchanged. A
+// time.Time encoded as a CQL date or time gives its date or time of day in
+// UTC, so the two always describe the same instant.
+func FromGo(v interface{}, spec *pb.TypeSpec) (*pb.Value, error) {
+	return encode(reflect.ValueOf(v), spec)
+}
+
+func encode(rv reflect.Value, spec *pb.TypeSpec) (*pb.Value, error) {
+	if !rv.IsValid() {
+		return NullValue(), nil
+	}
+
+	switch v := rv.Interface().(type) {
+	case *pb.Value:
+		if v == nil {
+			return NullValue(), nil
+		}
+		return v, nil
+	case uuid.UUID:
+		return encodeUUID(v, spec)
+	case *big.Int:
+		if v == nil {
+			return NullValue(), nil
+		}
+		return encodeBigInt(v, spec)
+	case *inf.Dec:
+		if v == nil {
+			return NullValue(), nil
+		}
+		return encodeDecimal(v, spec)
+	case big.Int:
+		return encodeBigInt(&v, spec)
+	case inf.Dec:
+		return encodeDecimal(&v, spec)
+	case time.Time:
+		return encodeTime(v, spec)
+	case time.Duration:
+		return encodeDuration(v, spec)
+	case net.IP:
+		if v == nil {
+			return NullValue(), nil
+		}
+		return encodeInet(v, spec)
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return NullValue(), nil
+		}
+		return encode(rv.Elem(), spec)
+	case reflect.Bool:
+		if !isBasic(spec, pb.TypeSpec_BOOLEAN) {
+			return nil, incompatible(rv, spec)
+		}
+		return FromBoolean(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return encodeInt(rv.Int(), rv, spec)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			return encodeBigInt(new(big.Int).SetUint64(u), spec)
+		}
+		return encodeInt(int64(u), rv, spec)
+	case reflect.Float32:
+		return encodeFloat(rv.Float(), pb.TypeSpec_FLOAT, rv, spec)
+	case reflect.Float64:
+		return encodeFloat(rv.Float(), pb.TypeSpec_DOUBLE, rv, spec)
+	case reflect.String:
+		return encodeString(rv.String(), rv, spec)
+	case reflect.Slice:
+		if rv.IsNil() {
+			return NullValue(), nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return encodeBytes(rv.Bytes(), rv, spec)
+		}
+		return encodeSequence(rv, spec)
+	case reflect.Array:
+		return encodeSequence(rv, spec)
+	case reflect.Map:
+		if rv.IsNil() {
+			return NullValue(), nil
+		}
+		return encodeMap(rv, spec)
+	case reflect.Struct:
+		return encodeStruct(rv, spec)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", rv.Type())
+}
+
+func encodeInt(i int64, rv reflect.Value, spec *pb.TypeSpec) (*pb.Value, error) {
+	if spec == nil {
+		return FromInt(i), nil
+	}
+
+	basic, ok := basicOf(spec)
+	if !ok {
+		return nil, incompatible(rv, spec)
+	}
+
+	switch basic {
+	case pb.TypeSpec_BIGINT, pb.TypeSpec_COUNTER, pb.TypeSpec_TIMESTAMP:
+		return FromInt(i), nil
+	case pb.TypeSpec_INT:
+		return checkedInt(i, math.MinInt32, math.MaxInt32, spec)
+	case pb.TypeSpec_SMALLINT:
+		return checkedInt(i, math.MinInt16, math.MaxInt16, spec)
+	case pb.TypeSpec_TINYINT:
+		return checkedInt(i, math.MinInt8, math.MaxInt8, spec)
+	case pb.TypeSpec_VARINT:
+		return FromVarInt(big.NewInt(i)), nil
+	case pb.TypeSpec_DECIMAL:
+		return FromDecimal(inf.NewDec(i, 0)), nil
+	case pb.TypeSpec_DOUBLE:
+		return FromDouble(float64(i)), nil
+	case pb.TypeSpec_FLOAT:
+		return FromFloat(float32(i)), nil
+	}
+
+	return nil, incompatible(rv, spec)
+}
+
+func checkedInt(i, min, max int64, spec *pb.TypeSpec) (*pb.Value, error) {
+	if i < min || i > max {
+		return nil, fmt.Errorf("value %d out of range for %s", i, spec.GetBasic())
+	}
+	return FromInt(i), nil
+}
+
+func encodeFloat(f float64, def pb.TypeSpec_Basic, rv reflect.Value, spec *pb.TypeSpec) (*pb.Value, error) {
+	basic := def
+	if spec != nil {
+		var ok bool
+		if basic, ok = basicOf(spec); !ok {
+			return nil, incompatible(rv, spec)
+		}
+	}
+
+	switch basic {
+	case pb.TypeSpec_FLOAT:
+		return FromFloat(float32(f)), nil
+	case pb.TypeSpec_DOUBLE:
+		return FromDouble(f), nil
+	case pb.TypeSpec_DECIMAL:
+		d, ok := new(inf.Dec).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+		if !ok {
+			return nil, fmt.Errorf("cannot represent %v as decimal", f)
+		}
+		return FromDecimal(d), nil
+	}
+
+	return nil, incompatible(rv, spec)
+}
+
+func encodeString(s string, rv reflect.Value, spec *pb.TypeSpec) (*pb.Value, error) {
+	if spec == nil {
+		return FromString(s), nil
+	}
+
+	basic, ok := basicOf(spec)
+	if !ok {
+		return nil, incompatible(rv, spec)
+	}
+
+	switch basic {
+	case pb.TypeSpec_ASCII, pb.TypeSpec_TEXT, pb.TypeSpec_VARCHAR:
+		return FromString(s), nil
+	case pb.TypeSpec_UUID, pb.TypeSpec_TIMEUUID:
+		u, err := uuid.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uuid %q: %w", s, err)
+		}
+		return FromUUID(u), nil
+	case pb.TypeSpec_INET:
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid inet %q", s)
+		}
+		return FromInet(ip), nil
+	case pb.TypeSpec_VARINT:
+		i, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid varint %q", s)
+		}
+		return FromVarInt(i), nil
+	case pb.TypeSpec_DECIMAL:
+		d, ok := new(inf.Dec).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid decimal %q", s)
+		}
+		return FromDecimal(d), nil
+	case pb.TypeSpec_BLOB, pb.TypeSpec_CUSTOM:
+		return FromBlob([]byte(s)), nil
+	}
+
+	return nil, incompatible(rv, spec)
+}
+
+func encodeBytes(b []byte, rv reflect.Value, spec *pb.TypeSpec) (*pb.Value, error) {
+	if spec == nil {
+		return FromBlob(b), nil
+	}
+
+	basic, ok := basicOf(spec)
+	if !ok {
+		return nil, incompatible(rv, spec)
+	}
+
+	switch basic {
+	case pb.TypeSpec_BLOB, pb.TypeSpec_CUSTOM:
+		return FromBlob(b), nil
+	case pb.TypeSpec_UUID, pb.TypeSpec_TIMEUUID:
+		u, err := uuid.FromBytes(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uuid: %w", err)
+		}
+		return FromUUID(u), nil
+	case pb.TypeSpec_INET:
+		if len(b) != net.IPv4len && len(b) != net.IPv6len {
+			return nil, fmt.Errorf("invalid inet length %d", len(b))
+		}
+		return FromInet(b), nil
+	}
+
+	return nil, incompatible(rv, spec)
+}
+
+func encodeUUID(u uuid.UUID, spec *pb.TypeSpec) (*pb.Value, error) {
+	if !isBasic(spec, pb.TypeSpec_UUID, pb.TypeSpec_TIMEUUID) {
+		return nil, incompatible(reflect.ValueOf(u), spec)
+	}
+	return FromUUID(u), nil
+}
+
+func encodeBigInt(i *big.Int, spec *pb.TypeSpec) (*pb.Value, error) {
+	switch {
+	case isBasic(spec, pb.TypeSpec_VARINT):
+		return FromVarInt(i), nil
+	case isBasic(spec, pb.TypeSpec_DECIMAL):
+		return FromDecimal(new(inf.Dec).SetUnscaledBig(i)), nil
+	case spec.GetSpec() != nil && i.IsInt64():
+		return encodeInt(i.Int64(), reflect.ValueOf(i), spec)
+	}
+
+	return nil, incompatible(reflect.ValueOf(i), spec)
+}
+
+func encodeDecimal(d *inf.Dec, spec *pb.TypeSpec) (*pb.Value, error) {
+	if !isBasic(spec, pb.TypeSpec_DECIMAL) {
+		return nil, incompatible(reflect.ValueOf(d), spec)
+	}
+	return FromDecimal(d), nil
+}
+
+func encodeTime(t time.Time, spec *pb.TypeSpec) (*pb.Value, error) {
+	if spec == nil {
+		return FromTimestamp(t), nil
+	}
+
+	switch spec.GetBasic() {
+	case pb.TypeSpec_TIMESTAMP:
+		return FromTimestamp(t), nil
+	case pb.TypeSpec_DATE:
+		return FromDate(t), nil
+	case pb.TypeSpec_TIME:
+		hour, min, sec := t.UTC().Clock()
+		return FromTime(time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute +
+			time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())), nil
+	}
+
+	return nil, incompatible(reflect.ValueOf(t), spec)
+}
+
+func encodeDuration(d time.Duration, spec *pb.TypeSpec) (*pb.Value, error) {
+	if !isBasic(spec, pb.TypeSpec_TIME) {
+		return encodeInt(int64(d), reflect.ValueOf(d), spec)
+	}
+	if d < 0 || d >= 24*time.Hour {
+		return nil, fmt.Errorf("time %v must be within a single day", d)
+	}
+	return FromTime(d), nil
+}
+
+func encodeInet(ip net.IP, spec *pb.TypeSpec) (*pb.Value, error) {
+	if !isBasic(spec, pb.TypeSpec_INET) {
+		return nil, incompatible(reflect.ValueOf(ip), spec)
+	}
+	if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+		return nil, fmt.Errorf("invalid inet length %d", len(ip))
+	}
+	return FromInet(ip), nil
+}
+
+func encodeSequence(rv reflect.Value, spec *pb.TypeSpec) (*pb.Value, error) {
+	var specs func(i int) *pb.TypeSpec
+	switch s := spec.GetSpec().(type) {
+	case nil:
+		specs = func(int) *pb.TypeSpec { return nil }
+	case *pb.TypeSpec_List_:
+		specs = func(int) *pb.TypeSpec { return s.List.GetElement() }
+	case *pb.TypeSpec_Set_:
+		specs = func(int) *pb.TypeSpec { return s.Set.GetElement() }
+	case *pb.TypeSpec_Tuple_:
+		if len(s.Tuple.GetElements()) != rv.Len() {
+			return nil, fmt.Errorf("tuple has %d elements, got %d", len(s.Tuple.GetElements()), rv.Len())
+		}
+		specs = func(i int) *pb.TypeSpec { return s.Tuple.GetElements()[i] }
+	default:
+		return nil, incompatible(rv, spec)
+	}
+
+	elements := make([]*pb.Value, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		element, err := encode(rv.Index(i), specs(i))
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		elements = append(elements, element)
+	}
+
+	return FromCollection(elements...), nil
+}
+
+func encodeMap(rv reflect.Value, spec *pb.TypeSpec) (*pb.Value, error) {
+	switch s := spec.GetSpec().(type) {
+	case *pb.TypeSpec_Udt_:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, incompatible(rv, spec)
+		}
+		fields := make(map[string]*pb.Value, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			name := iter.Key().String()
+			fieldSpec, ok := s.Udt.GetFields()[name]
+			if !ok {
+				return nil, fmt.Errorf("udt has no field %q", name)
+			}
+			field, err := encode(iter.Value(), fieldSpec)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", name, err)
+			}
+			fields[name] = field
+		}
+		return FromUDT(fields), nil
+	case *pb.TypeSpec_Set_:
+		return encodeKeys(rv, s.Set.GetElement())
+	case nil:
+		if rv.Type().Elem() == reflect.TypeOf(struct{}{}) {
+			return encodeKeys(rv, nil)
+		}
+	case *pb.TypeSpec_Map_:
+	default:
+		return nil, incompatible(rv, spec)
+	}
+
+	elements := make([]*pb.Value, 0, rv.Len()*2)
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := encode(iter.Key(), spec.GetMap().GetKey())
+		if err != nil {
+			return nil, fmt.Errorf("map key: %w", err)
+		}
+		val, err := encode(iter.Value(), spec.GetMap().GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("map value: %w", err)
+		}
+		elements = append(elements, key, val)
+	}
+
+	return FromCollection(elements...), nil
+}
+
+// encodeKeys encodes the keys of a map as a set, allowing map[T]struct{} and
+// map[T]bool to be used for set columns.
+func encodeKeys(rv reflect.Value, elementSpec *pb.TypeSpec) (*pb.Value, error) {
+	elements := make([]*pb.Value, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		if iter.Value().Kind() == reflect.Bool && !iter.Value().Bool() {
+			continue
+		}
+		element, err := encode(iter.Key(), elementSpec)
+		if err != nil {
+			return nil, fmt.Errorf("set element: %w", err)
+		}
+		elements = append(elements, element)
+	}
+
+	return FromCollection(elements...), nil
+}
+
+func encodeStruct(rv reflect.Value, spec *pb.TypeSpec) (*pb.Value, error) {
+	if spec != nil && spec.GetUdt() == nil {
+		return nil, incompatible(rv, spec)
+	}
+
+	fields := map[string]*pb.Value{}
+	for _, field := range cachedStructInfo(rv.Type()).fields {
+		fv, ok := fieldByIndexNoAlloc(rv, field.index)
+		if !ok || (field.omitEmpty && fv.IsZero()) {
+			continue
+		}
+
+		var fieldSpec *pb.TypeSpec
+		if spec != nil {
+			if fieldSpec, ok = spec.GetUdt().GetFields()[field.name]; !ok {
+				return nil, fmt.Errorf("udt has no field %q", field.name)
+			}
+		}
+
+		value, err := encode(fv, fieldSpec)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.name, err)
+		}
+		fields[field.name] = value
+	}
+
+	return FromUDT(fields), nil
+}
+
+// encodeVarint returns the minimal big-endian two's complement representation
+// of i, as used by the CQL varint and decimal types.
+func encodeVarint(i *big.Int) []byte {
+	switch i.Sign() {
+	case 0:
+		return []byte{0}
+	case 1:
+		b := i.Bytes()
+		if b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+		return b
+	}
+
+	length := uint(i.BitLen()/8 + 1)
+	b := new(big.Int).Add(i, new(big.Int).Lsh(big.NewInt(1), length*8)).Bytes()
+	if len(b) >= 2 && b[0] == 0xff && b[1]&0x80 != 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+// basicOf returns the basic type of spec, or false if spec describes a
+// collection, tuple or UDT.
+func basicOf(spec *pb.TypeSpec) (pb.TypeSpec_Basic, bool) {
+	s, ok := spec.GetSpec().(*pb.TypeSpec_Basic_)
+	if !ok {
+		return 0, false
+	}
+	return s.Basic, true
+}
+
+// isBasic reports whether spec is nil or one of the allowed basic types.
+func isBasic(spec *pb.TypeSpec, allowed ...pb.TypeSpec_Basic) bool {
+	if spec == nil {
+		return true
+	}
+	basic, ok := basicOf(spec)
+	if !ok {
+		return false
+	}
+	for _, a := range allowed {
+		if basic == a {
+			return true
+		}
+	}
+	return false
+}
+
+func incompatible(rv reflect.Value, spec *pb.TypeSpec) error {
+	return fmt.Errorf("cannot encode %s as %s", rv.Type(), describeSpec(spec))
+}
+
+func describeSpec(spec *pb.TypeSpec) string {
+	switch s := spec.GetSpec().(type) {
+	case *pb.TypeSpec_Basic_:
+		return strings.ToLower(s.Basic.String())
+	case *pb.TypeSpec_Map_:
+		return fmt.Sprintf("map<%s, %s>", describeSpec(s.Map.GetKey()), describeSpec(s.Map.GetValue()))
+	case *pb.TypeSpec_List_:
+		return fmt.Sprintf("list<%s>", describeSpec(s.List.GetElement()))
+	case *pb.TypeSpec_Set_:
+		return fmt.Sprintf("set<%s>", describeSpec(s.Set.GetElement()))
+	case *pb.TypeSpec_Udt_:
+		return "udt"
+	case *pb.TypeSpec_Tuple_:
+		elements := make([]string, 0, len(s.Tuple.GetElements()))
+		for _, element := range s.Tuple.GetElements() {
+			elements = append(elements, describeSpec(element))
+		}
+		return fmt.Sprintf("tuple<%s>", strings.Join(elements, ", "))
+	}
+	return "unknown type"
+}
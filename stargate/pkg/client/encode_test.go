@@ -0,0 +1,125 @@
+package client
+
+import (
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/inf.v0"
+)
+
+func basicSpec(basic pb.TypeSpec_Basic) *pb.TypeSpec {
+	return &pb.TypeSpec{Spec: &pb.TypeSpec_Basic_{Basic: basic}}
+}
+
+func TestFromGo_Basic(t *testing.T) {
+	id := uuid.MustParse("f066f76d-5e96-4b52-8d8a-0f51387df76b")
+	ts := time.Date(2021, 9, 7, 16, 40, 31, 123000000, time.UTC)
+	// The same instant, on the next day in Sydney.
+	sydney := ts.In(time.FixedZone("AEST", 10*60*60))
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		spec     *pb.TypeSpec
+		expected *pb.Value
+	}{
+		{"nil", nil, nil, NullValue()},
+		{"nil pointer", (*string)(nil), basicSpec(pb.TypeSpec_TEXT), NullValue()},
+		{"unset", UnsetValue(), basicSpec(pb.TypeSpec_INT), UnsetValue()},
+		{"string", "alpha", nil, FromString("alpha")},
+		{"string pointer", stringPtr("alpha"), basicSpec(pb.TypeSpec_VARCHAR), FromString("alpha")},
+		{"int", 42, nil, FromInt(42)},
+		{"int as varint", 42, basicSpec(pb.TypeSpec_VARINT), FromVarInt(big.NewInt(42))},
+		{"bool", true, nil, FromBoolean(true)},
+		{"float32", float32(3.3), nil, FromFloat(3.3)},
+		{"float64", 2.2, nil, FromDouble(2.2)},
+		{"float64 as float", 2.5, basicSpec(pb.TypeSpec_FLOAT), FromFloat(2.5)},
+		{"blob", []byte("foo"), nil, FromBlob([]byte("foo"))},
+		{"uuid", id, nil, FromUUID(id)},
+		{"uuid string", id.String(), basicSpec(pb.TypeSpec_UUID), FromUUID(id)},
+		{"inet", net.ParseIP("127.0.0.1"), nil, &pb.Value{Inner: &pb.Value_Inet{Inet: &pb.Inet{Value: []byte{127, 0, 0, 1}}}}},
+		{"varint", big.NewInt(-129), nil, &pb.Value{Inner: &pb.Value_Varint{Varint: &pb.Varint{Value: []byte{0xff, 0x7f}}}}},
+		{"decimal", inf.NewDec(11, 1), nil, &pb.Value{Inner: &pb.Value_Decimal{Decimal: &pb.Decimal{Scale: 1, Value: []byte{0x0b}}}}},
+		{"timestamp", ts, nil, FromInt(1631032831123)},
+		{"date", ts, basicSpec(pb.TypeSpec_DATE), &pb.Value{Inner: &pb.Value_Date{Date: 0x800049bd}}},
+		{"time", ts, basicSpec(pb.TypeSpec_TIME), FromTime(16*time.Hour + 40*time.Minute + 31*time.Second + 123*time.Millisecond)},
+		{"date in utc", sydney, basicSpec(pb.TypeSpec_DATE), &pb.Value{Inner: &pb.Value_Date{Date: 0x800049bd}}},
+		{"time in utc", sydney, basicSpec(pb.TypeSpec_TIME), FromTime(16*time.Hour + 40*time.Minute + 31*time.Second + 123*time.Millisecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := FromGo(tt.value, tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.String(), value.String())
+		})
+	}
+}
+
+func TestFromGo_Collections(t *testing.T) {
+	list := &pb.TypeSpec{Spec: &pb.TypeSpec_List_{List: &pb.TypeSpec_List{Element: basicSpec(pb.TypeSpec_TEXT)}}}
+	value, err := FromGo([]string{"a", "b"}, list)
+	require.NoError(t, err)
+	assert.Equal(t, FromCollection(FromString("a"), FromString("b")).String(), value.String())
+
+	mapSpec := &pb.TypeSpec{Spec: &pb.TypeSpec_Map_{Map: &pb.TypeSpec_Map{
+		Key:   basicSpec(pb.TypeSpec_INT),
+		Value: basicSpec(pb.TypeSpec_TEXT),
+	}}}
+	value, err = FromGo(map[int]string{1: "a"}, mapSpec)
+	require.NoError(t, err)
+	assert.Equal(t, FromCollection(FromInt(1), FromString("a")).String(), value.String())
+
+	tuple := &pb.TypeSpec{Spec: &pb.TypeSpec_Tuple_{Tuple: &pb.TypeSpec_Tuple{Elements: []*pb.TypeSpec{
+		basicSpec(pb.TypeSpec_INT),
+		basicSpec(pb.TypeSpec_TEXT),
+		basicSpec(pb.TypeSpec_FLOAT),
+	}}}}
+	value, err = FromGo([]interface{}{3, "bar", 2.1}, tuple)
+	require.NoError(t, err)
+	assert.Equal(t, FromCollection(FromInt(3), FromString("bar"), FromFloat(2.1)).String(), value.String())
+
+	_, err = FromGo([]interface{}{3}, tuple)
+	assert.Error(t, err)
+}
+
+func TestFromGo_UDT(t *testing.T) {
+	type address struct {
+		Street string `cql:"street"`
+		Zip    *int   `cql:"zip_code"`
+		Ignore string `cql:"-"`
+	}
+
+	udt := &pb.TypeSpec{Spec: &pb.TypeSpec_Udt_{Udt: &pb.TypeSpec_Udt{Fields: map[string]*pb.TypeSpec{
+		"street":   basicSpec(pb.TypeSpec_TEXT),
+		"zip_code": basicSpec(pb.TypeSpec_INT),
+	}}}}
+
+	value, err := FromGo(address{Street: "Main"}, udt)
+	require.NoError(t, err)
+	assert.Equal(t, FromUDT(map[string]*pb.Value{
+		"street":   FromString("Main"),
+		"zip_code": NullValue(),
+	}).String(), value.String())
+}
+
+func TestFromGo_Incompatible(t *testing.T) {
+	_, err := FromGo("alpha", basicSpec(pb.TypeSpec_INT))
+	assert.EqualError(t, err, "cannot encode string as int")
+
+	_, err = FromGo(1<<40, basicSpec(pb.TypeSpec_INT))
+	assert.Error(t, err)
+
+	_, err = FromGo(true, &pb.TypeSpec{Spec: &pb.TypeSpec_List_{List: &pb.TypeSpec_List{}}})
+	assert.Error(t, err)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,294 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LoadBalancingPolicy selects how requests are spread across endpoints.
+type LoadBalancingPolicy int
+
+const (
+	// RoundRobin sends requests to each available endpoint in turn.
+	RoundRobin LoadBalancingPolicy = iota
+	// LeastOutstanding sends requests to the available endpoint with the
+	// fewest requests in flight.
+	LeastOutstanding
+)
+
+const (
+	defaultFailureThreshold = 3
+	defaultMinProbeBackoff  = time.Second
+	defaultMaxProbeBackoff  = time.Minute
+)
+
+// EndpointHealth is the health of one endpoint of a client connected to
+// several.
+type EndpointHealth struct {
+	Address string
+	// Datacenter is the datacenter the endpoint was configured in, if any.
+	Datacenter string
+	// Up is false once the endpoint has failed FailureThreshold consecutive
+	// requests, until a probe request succeeds.
+	Up bool
+	// ConsecutiveFailures is the number of requests that have failed since
+	// the last success.
+	ConsecutiveFailures int
+	// Outstanding is the number of requests in flight.
+	Outstanding int64
+	// NextProbe is when a request will next be sent to an endpoint that is
+	// down.
+	NextProbe time.Time
+}
+
+type endpoint struct {
+	address     string
+	datacenter  string
+	client      pb.StargateClient
+	outstanding int64
+
+	mu       sync.Mutex
+	failures int
+	down     bool
+	probing  bool
+	backoff  time.Duration
+	retryAt  time.Time
+}
+
+// endpointGroup is the endpoints of one datacenter.
+type endpointGroup struct {
+	datacenter string
+	endpoints  []*endpoint
+	next       uint64
+}
+
+// endpointPool is a pb.StargateClient spreading requests across several
+// endpoints, taking endpoints that keep failing out of rotation and probing
+// them with exponential back-off until they recover. Endpoints are grouped by
+// datacenter, and a group is only used when none of the preceding ones has an
+// available endpoint.
+type endpointPool struct {
+	endpoints                []*endpoint
+	groups                   []*endpointGroup
+	policy                   LoadBalancingPolicy
+	failureThreshold         int
+	minBackoff               time.Duration
+	maxBackoff               time.Duration
+	localConsistencyFailover bool
+	now                      func() time.Time
+}
+
+func newEndpointPool(addresses []string, clients []pb.StargateClient, config Config) *endpointPool {
+	p := &endpointPool{
+		policy:                   config.LoadBalancing,
+		failureThreshold:         config.FailureThreshold,
+		minBackoff:               config.MinProbeBackoff,
+		maxBackoff:               config.MaxProbeBackoff,
+		localConsistencyFailover: config.LocalConsistencyFailover,
+		now:                      time.Now,
+	}
+	if p.failureThreshold <= 0 {
+		p.failureThreshold = defaultFailureThreshold
+	}
+	if p.minBackoff <= 0 {
+		p.minBackoff = defaultMinProbeBackoff
+	}
+	if p.maxBackoff < p.minBackoff {
+		p.maxBackoff = defaultMaxProbeBackoff
+	}
+
+	byAddress := make(map[string]*endpoint, len(addresses))
+	for i, c := range clients {
+		e := &endpoint{
+			address: addresses[i],
+			client:  c,
+		}
+		p.endpoints = append(p.endpoints, e)
+		byAddress[e.address] = e
+	}
+
+	if len(config.Datacenters) == 0 {
+		p.groups = []*endpointGroup{{endpoints: p.endpoints}}
+		return p
+	}
+	for _, dc := range config.datacenterOrder() {
+		g := &endpointGroup{datacenter: dc.Name}
+		for _, address := range dc.Endpoints {
+			e := byAddress[address]
+			e.datacenter = dc.Name
+			g.endpoints = append(g.endpoints, e)
+		}
+		p.groups = append(p.groups, g)
+	}
+	return p
+}
+
+func (p *endpointPool) ExecuteQuery(ctx context.Context, in *pb.Query, opts ...grpc.CallOption) (*pb.Response, error) {
+	return p.execute(ctx, isLocalConsistency(in.GetParameters()), func(c pb.StargateClient) (*pb.Response, error) {
+		return c.ExecuteQuery(ctx, in, opts...)
+	})
+}
+
+func (p *endpointPool) ExecuteBatch(ctx context.Context, in *pb.Batch, opts ...grpc.CallOption) (*pb.Response, error) {
+	return p.execute(ctx, isLocalConsistency(in.GetParameters()), func(c pb.StargateClient) (*pb.Response, error) {
+		return c.ExecuteBatch(ctx, in, opts...)
+	})
+}
+
+func (p *endpointPool) execute(ctx context.Context, local bool, call func(c pb.StargateClient) (*pb.Response, error)) (*pb.Response, error) {
+	e, err := p.pick(local)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&e.outstanding, 1)
+	resp, err := call(e.client)
+	atomic.AddInt64(&e.outstanding, -1)
+
+	p.record(ctx, e, err)
+	return resp, err
+}
+
+// pick returns the endpoint to send the next request to, trying each
+// datacenter in turn. Requests that must stay in the local datacenter fail
+// rather than go to a remote one, unless local consistency failover is
+// allowed.
+func (p *endpointPool) pick(local bool) (*endpoint, error) {
+	now := p.now()
+	for i, g := range p.groups {
+		if i > 0 && local && !p.localConsistencyFailover {
+			return nil, status.Errorf(codes.Unavailable,
+				"no endpoint in local datacenter %s is available and remote failover is not allowed for LOCAL_* consistency",
+				p.groups[0].datacenter)
+		}
+		if e := p.pickFrom(g, now); e != nil {
+			return e, nil
+		}
+	}
+	return nil, status.Error(codes.Unavailable, "all endpoints are down")
+}
+
+// pickFrom returns the endpoint of g to send the next request to, or nil if
+// every endpoint is down and none is due to be probed. An endpoint due to be
+// probed takes priority, so that it is back in rotation as soon as it
+// recovers.
+func (p *endpointPool) pickFrom(g *endpointGroup, now time.Time) *endpoint {
+	for _, e := range g.endpoints {
+		e.mu.Lock()
+		due := e.down && !e.probing && !now.Before(e.retryAt)
+		if due {
+			e.probing = true
+		}
+		e.mu.Unlock()
+		if due {
+			return e
+		}
+	}
+
+	start := int(atomic.AddUint64(&g.next, 1) - 1)
+	var best *endpoint
+	for i := range g.endpoints {
+		e := g.endpoints[(start+i)%len(g.endpoints)]
+
+		e.mu.Lock()
+		up := !e.down
+		e.mu.Unlock()
+
+		if !up {
+			continue
+		}
+		if p.policy == RoundRobin {
+			return e
+		}
+		if best == nil || atomic.LoadInt64(&e.outstanding) < atomic.LoadInt64(&best.outstanding) {
+			best = e
+		}
+	}
+	return best
+}
+
+func (p *endpointPool) record(ctx context.Context, e *endpoint, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	wasProbe := e.probing
+	e.probing = false
+
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up on the request, which says nothing about
+		// whether the endpoint can be reached.
+		return
+	}
+	if !isEndpointFailure(err) {
+		e.failures = 0
+		e.down = false
+		e.backoff = 0
+		return
+	}
+
+	e.failures++
+	switch {
+	case e.down && wasProbe:
+		e.backoff *= 2
+		if e.backoff > p.maxBackoff {
+			e.backoff = p.maxBackoff
+		}
+	case !e.down && e.failures >= p.failureThreshold:
+		e.down = true
+		e.backoff = p.minBackoff
+	default:
+		return
+	}
+	e.retryAt = p.now().Add(e.backoff)
+}
+
+// isEndpointFailure reports whether err shows the gateway itself could not be
+// reached, as opposed to a failure reported by Cassandra through it. A
+// deadline exceeded without Cassandra error details, while the caller's own
+// deadline has not, means the gateway did not answer in time.
+func isEndpointFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return !IsUnavailable(decodeError(err))
+	case codes.DeadlineExceeded:
+		return len(status.Convert(err).Details()) == 0
+	}
+	return false
+}
+
+func (p *endpointPool) health() []EndpointHealth {
+	health := make([]EndpointHealth, len(p.endpoints))
+	for i, e := range p.endpoints {
+		e.mu.Lock()
+		health[i] = EndpointHealth{
+			Address:             e.address,
+			Datacenter:          e.datacenter,
+			Up:                  !e.down,
+			ConsecutiveFailures: e.failures,
+			Outstanding:         atomic.LoadInt64(&e.outstanding),
+		}
+		if e.down {
+			health[i].NextProbe = e.retryAt
+		}
+		e.mu.Unlock()
+	}
+	return health
+}
+
+// EndpointHealth returns the health of each endpoint when the client was
+// created by Dial or NewStargateClient with several endpoints, and nil
+// otherwise.
+func (s *StargateClient) EndpointHealth() []EndpointHealth {
+	pool, ok := s.client.(*endpointPool)
+	if !ok {
+		return nil
+	}
+	return pool.health()
+}
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// switchableStargate is a fakeStargate answering from the named endpoint, or
+// failing as if it could not be reached while down is set.
+type switchableStargate struct {
+	fakeStargate
+	mu   sync.Mutex
+	down bool
+	hits int
+}
+
+func newSwitchableStargate(name string) *switchableStargate {
+	s := &switchableStargate{}
+	s.executeQuery = func(context.Context, *pb.Query) (*pb.Response, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.hits++
+		if s.down {
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		}
+		return &pb.Response{Result: &pb.Response_ResultSet{ResultSet: &pb.ResultSet{
+			Rows: []*pb.Row{{Values: []*pb.Value{{Inner: &pb.Value_String_{String_: name}}}}},
+		}}}, nil
+	}
+	return s
+}
+
+func (s *switchableStargate) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func newTestPool(config Config, fakes ...*switchableStargate) *endpointPool {
+	addresses := make([]string, len(fakes))
+	clients := make([]pb.StargateClient, len(fakes))
+	for i, f := range fakes {
+		addresses[i] = string(rune('a'+i)) + ":8090"
+		clients[i] = f
+	}
+	return newEndpointPool(addresses, clients, config)
+}
+
+func answeredBy(t *testing.T, p *endpointPool) string {
+	t.Helper()
+	resp, err := p.ExecuteQuery(context.Background(), &pb.Query{})
+	require.NoError(t, err)
+	return resp.GetResultSet().GetRows()[0].GetValues()[0].GetString_()
+}
+
+func TestEndpointPool_RoundRobin(t *testing.T) {
+	p := newTestPool(Config{}, newSwitchableStargate("a"), newSwitchableStargate("b"), newSwitchableStargate("c"))
+
+	var got []string
+	for i := 0; i < 6; i++ {
+		got = append(got, answeredBy(t, p))
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a", "b", "c"}, got)
+}
+
+func TestEndpointPool_LeastOutstanding(t *testing.T) {
+	busy := &switchableStargate{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	busy.executeQuery = func(context.Context, *pb.Query) (*pb.Response, error) {
+		close(started)
+		<-release
+		return &pb.Response{}, nil
+	}
+	p := newTestPool(Config{LoadBalancing: LeastOutstanding}, busy, newSwitchableStargate("b"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = p.ExecuteQuery(context.Background(), &pb.Query{})
+	}()
+	<-started
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "b", answeredBy(t, p))
+	}
+	assert.Equal(t, int64(1), p.health()[0].Outstanding)
+
+	close(release)
+	<-done
+}
+
+func TestEndpointPool_MarksDownAndProbes(t *testing.T) {
+	now := time.Unix(0, 0)
+	a, b := newSwitchableStargate("a"), newSwitchableStargate("b")
+	p := newTestPool(Config{FailureThreshold: 2, MinProbeBackoff: time.Second, MaxProbeBackoff: 3 * time.Second}, a, b)
+	p.now = func() time.Time { return now }
+
+	a.setDown(true)
+	for i := 0; i < 4; i++ {
+		_, _ = p.ExecuteQuery(context.Background(), &pb.Query{})
+	}
+	health := p.health()
+	assert.False(t, health[0].Up)
+	assert.Equal(t, 2, health[0].ConsecutiveFailures)
+	assert.Equal(t, now.Add(time.Second), health[0].NextProbe)
+	assert.True(t, health[1].Up)
+
+	// Down endpoints are skipped until they are due to be probed.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "b", answeredBy(t, p))
+	}
+	assert.Equal(t, 2, a.hits)
+
+	// A failed probe doubles the back-off, up to the maximum.
+	now = now.Add(time.Second)
+	_, err := p.ExecuteQuery(context.Background(), &pb.Query{})
+	assert.Equal(t, codes.Unavailable, StatusCode(err))
+	assert.Equal(t, now.Add(2*time.Second), p.health()[0].NextProbe)
+	now = now.Add(2 * time.Second)
+	_, _ = p.ExecuteQuery(context.Background(), &pb.Query{})
+	assert.Equal(t, now.Add(3*time.Second), p.health()[0].NextProbe)
+
+	// A successful probe brings the endpoint back into rotation.
+	a.setDown(false)
+	now = now.Add(3 * time.Second)
+	assert.Equal(t, "a", answeredBy(t, p))
+	assert.Equal(t, EndpointHealth{Address: "a:8090", Up: true}, p.health()[0])
+}
+
+func TestEndpointPool_AllDown(t *testing.T) {
+	a := newSwitchableStargate("a")
+	a.setDown(true)
+	p := newTestPool(Config{FailureThreshold: 1}, a)
+
+	_, _ = p.ExecuteQuery(context.Background(), &pb.Query{})
+	_, err := p.ExecuteQuery(context.Background(), &pb.Query{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all endpoints are down")
+	assert.Equal(t, 1, a.hits)
+}
+
+func TestEndpointPool_CassandraErrorsKeepEndpointUp(t *testing.T) {
+	a := &switchableStargate{}
+	a.executeQuery = func(context.Context, *pb.Query) (*pb.Response, error) {
+		return nil, statusWithDetails(t, codes.Unavailable, &pb.Unavailable{Required: 2, Alive: 1})
+	}
+	p := newTestPool(Config{FailureThreshold: 1}, a)
+
+	for i := 0; i < 3; i++ {
+		_, err := p.ExecuteQuery(context.Background(), &pb.Query{})
+		assert.True(t, IsUnavailable(decodeError(err)))
+	}
+	assert.True(t, p.health()[0].Up)
+}
+
+func TestEndpointPool_GatewayDeadlineIsFailure(t *testing.T) {
+	a := &switchableStargate{}
+	a.executeQuery = func(context.Context, *pb.Query) (*pb.Response, error) {
+		return nil, status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	}
+	p := newTestPool(Config{FailureThreshold: 2}, a, newSwitchableStargate("b"))
+
+	for i := 0; i < 4; i++ {
+		_, _ = p.ExecuteQuery(context.Background(), &pb.Query{})
+	}
+	assert.False(t, p.health()[0].Up)
+}
+
+func TestEndpointPool_CancelledProbeIsInconclusive(t *testing.T) {
+	now := time.Unix(0, 0)
+	a := newSwitchableStargate("a")
+	a.setDown(true)
+	p := newTestPool(Config{FailureThreshold: 1, MinProbeBackoff: time.Second}, a, newSwitchableStargate("b"))
+	p.now = func() time.Time { return now }
+
+	_, _ = p.ExecuteQuery(context.Background(), &pb.Query{})
+	require.False(t, p.health()[0].Up)
+
+	a.executeQuery = func(ctx context.Context, _ *pb.Query) (*pb.Response, error) {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	now = now.Add(time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := p.ExecuteQuery(ctx, &pb.Query{})
+	assert.Equal(t, codes.Canceled, StatusCode(err))
+
+	health := p.health()[0]
+	assert.False(t, health.Up)
+	assert.Equal(t, 1, health.ConsecutiveFailures)
+	assert.Equal(t, now, health.NextProbe)
+
+	// The endpoint is still due to be probed.
+	a.executeQuery = newSwitchableStargate("a").executeQuery
+	assert.Equal(t, "a", answeredBy(t, p))
+	assert.True(t, p.health()[0].Up)
+}
+
+func TestEndpointPool_FailoverWithRetries(t *testing.T) {
+	a, b := newSwitchableStargate("a"), newSwitchableStargate("b")
+	a.setDown(true)
+	s := newStargateClient(newTestPool(Config{}, a, b), WithRetryPolicy(NewDefaultRetryPolicy()))
+
+	for i := 0; i < 4; i++ {
+		_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM system.local"})
+		require.NoError(t, err)
+	}
+	assert.Len(t, s.EndpointHealth(), 2)
+	assert.Nil(t, newFakeClient(&fakeStargate{}).EndpointHealth())
+}
+
+func TestDial_MultipleEndpoints(t *testing.T) {
+	first, a := startTokenServer(t, "token")
+	second, b := startTokenServer(t, "token")
+
+	s, err := Dial(context.Background(), Config{
+		Endpoint:  a,
+		Endpoints: []string{b},
+		Insecure:  true,
+		Token:     "token",
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 4; i++ {
+		_, err = s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM system.local"})
+		require.NoError(t, err)
+	}
+	assert.Len(t, first.tokens, 2)
+	assert.Len(t, second.tokens, 2)
+	assert.Equal(t, []string{a, b}, []string{s.EndpointHealth()[0].Address, s.EndpointHealth()[1].Address})
+}
+
+func TestDial_UnreachableEndpointsMarkedDown(t *testing.T) {
+	s, err := Dial(context.Background(), Config{
+		Endpoint:         "127.0.0.1:1",
+		Endpoints:        []string{"127.0.0.1:2"},
+		Insecure:         true,
+		FailureThreshold: 2,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 4; i++ {
+		_, err = s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM system.local"})
+		require.Error(t, err)
+	}
+	for _, health := range s.EndpointHealth() {
+		assert.False(t, health.Up, health.Address)
+		assert.Equal(t, 2, health.ConsecutiveFailures, health.Address)
+	}
+}
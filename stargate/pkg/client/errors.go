@@ -0,0 +1,276 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WriteType is the kind of write that failed or timed out, as reported by
+// Cassandra in WriteTimeoutError and WriteFailureError.
+type WriteType string
+
+const (
+	WriteTypeSimple        WriteType = "SIMPLE"
+	WriteTypeBatch         WriteType = "BATCH"
+	WriteTypeUnloggedBatch WriteType = "UNLOGGED_BATCH"
+	WriteTypeCounter       WriteType = "COUNTER"
+	WriteTypeBatchLog      WriteType = "BATCH_LOG"
+	WriteTypeCAS           WriteType = "CAS"
+	WriteTypeView          WriteType = "VIEW"
+	WriteTypeCDC           WriteType = "CDC"
+)
+
+// statusError is embedded by the typed errors so that they keep the message
+// and status of the original gRPC error.
+type statusError struct {
+	status *status.Status
+}
+
+func (e statusError) Error() string {
+	return e.status.Err().Error()
+}
+
+// GRPCStatus returns the gRPC status the error was decoded from.
+func (e statusError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// UnavailableError is returned when not enough replicas are alive to satisfy
+// the requested consistency level.
+type UnavailableError struct {
+	statusError
+	Consistency pb.Consistency
+	Required    int32
+	Alive       int32
+}
+
+// WriteTimeoutError is returned when replicas did not acknowledge a write in
+// time.
+type WriteTimeoutError struct {
+	statusError
+	Consistency pb.Consistency
+	Received    int32
+	BlockFor    int32
+	WriteType   WriteType
+}
+
+// ReadTimeoutError is returned when replicas did not respond to a read in
+// time.
+type ReadTimeoutError struct {
+	statusError
+	Consistency pb.Consistency
+	Received    int32
+	BlockFor    int32
+	DataPresent bool
+}
+
+// ReadFailureError is returned when replicas failed to serve a read.
+type ReadFailureError struct {
+	statusError
+	Consistency pb.Consistency
+	Received    int32
+	BlockFor    int32
+	NumFailures int32
+	DataPresent bool
+}
+
+// WriteFailureError is returned when replicas failed to apply a write.
+type WriteFailureError struct {
+	statusError
+	Consistency pb.Consistency
+	Received    int32
+	BlockFor    int32
+	NumFailures int32
+	WriteType   WriteType
+}
+
+// FunctionFailureError is returned when a user defined function failed.
+type FunctionFailureError struct {
+	statusError
+	Keyspace string
+	Function string
+	ArgTypes []string
+}
+
+// AlreadyExistsError is returned when creating a keyspace or table that
+// already exists.
+type AlreadyExistsError struct {
+	statusError
+	Keyspace string
+	Table    string
+}
+
+// CasWriteUnknownError is returned when the outcome of a lightweight
+// transaction could not be determined.
+type CasWriteUnknownError struct {
+	statusError
+	Consistency pb.Consistency
+	Received    int32
+	BlockFor    int32
+}
+
+// decodeError converts a gRPC error into one of the typed errors above if its
+// status carries Stargate error details, otherwise err is returned unchanged.
+func decodeError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	base := statusError{status: st}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *pb.Unavailable:
+			return &UnavailableError{
+				statusError: base,
+				Consistency: d.GetConsistency(),
+				Required:    d.GetRequired(),
+				Alive:       d.GetAlive(),
+			}
+		case *pb.WriteTimeout:
+			return &WriteTimeoutError{
+				statusError: base,
+				Consistency: d.GetConsistency(),
+				Received:    d.GetReceived(),
+				BlockFor:    d.GetBlockFor(),
+				WriteType:   WriteType(d.GetWriteType()),
+			}
+		case *pb.ReadTimeout:
+			return &ReadTimeoutError{
+				statusError: base,
+				Consistency: d.GetConsistency(),
+				Received:    d.GetReceived(),
+				BlockFor:    d.GetBlockFor(),
+				DataPresent: d.GetDataPresent(),
+			}
+		case *pb.ReadFailure:
+			return &ReadFailureError{
+				statusError: base,
+				Consistency: d.GetConsistency(),
+				Received:    d.GetReceived(),
+				BlockFor:    d.GetBlockFor(),
+				NumFailures: d.GetNumFailures(),
+				DataPresent: d.GetDataPresent(),
+			}
+		case *pb.WriteFailure:
+			return &WriteFailureError{
+				statusError: base,
+				Consistency: d.GetConsistency(),
+				Received:    d.GetReceived(),
+				BlockFor:    d.GetBlockFor(),
+				NumFailures: d.GetNumFailures(),
+				WriteType:   WriteType(d.GetWriteType()),
+			}
+		case *pb.FunctionFailure:
+			return &FunctionFailureError{
+				statusError: base,
+				Keyspace:    d.GetKeyspace(),
+				Function:    d.GetFunction(),
+				ArgTypes:    d.GetArgTypes(),
+			}
+		case *pb.AlreadyExists:
+			return &AlreadyExistsError{
+				statusError: base,
+				Keyspace:    d.GetKeyspace(),
+				Table:       d.GetTable(),
+			}
+		case *pb.CasWriteUnknown:
+			return &CasWriteUnknownError{
+				statusError: base,
+				Consistency: d.GetConsistency(),
+				Received:    d.GetReceived(),
+				BlockFor:    d.GetBlockFor(),
+			}
+		}
+	}
+
+	return err
+}
+
+// StatusCode returns the gRPC status code of err, unwrapping it as needed. It
+// returns codes.OK for a nil error and codes.Unknown if err did not originate
+// from gRPC.
+func StatusCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		return se.GRPCStatus().Code()
+	}
+	return codes.Unknown
+}
+
+// errorClass returns a short name for the kind of error, used to group
+// failures: the name of the typed error for failures reported by Cassandra,
+// otherwise the gRPC status code.
+func errorClass(err error) string {
+	switch {
+	case IsUnavailable(err):
+		return "UnavailableError"
+	case IsReadTimeout(err):
+		return "ReadTimeoutError"
+	case IsWriteTimeout(err):
+		return "WriteTimeoutError"
+	case IsReadFailure(err):
+		return "ReadFailureError"
+	case IsWriteFailure(err):
+		return "WriteFailureError"
+	case IsFunctionFailure(err):
+		return "FunctionFailureError"
+	case IsAlreadyExists(err):
+		return "AlreadyExistsError"
+	case IsCasWriteUnknown(err):
+		return "CasWriteUnknownError"
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded.String()
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled.String()
+	}
+	return StatusCode(err).String()
+}
+
+func IsUnavailable(err error) bool {
+	var target *UnavailableError
+	return errors.As(err, &target)
+}
+
+func IsWriteTimeout(err error) bool {
+	var target *WriteTimeoutError
+	return errors.As(err, &target)
+}
+
+func IsReadTimeout(err error) bool {
+	var target *ReadTimeoutError
+	return errors.As(err, &target)
+}
+
+func IsReadFailure(err error) bool {
+	var target *ReadFailureError
+	return errors.As(err, &target)
+}
+
+func IsWriteFailure(err error) bool {
+	var target *WriteFailureError
+	return errors.As(err, &target)
+}
+
+func IsFunctionFailure(err error) bool {
+	var target *FunctionFailureError
+	return errors.As(err, &target)
+}
+
+func IsAlreadyExists(err error) bool {
+	var target *AlreadyExistsError
+	return errors.As(err, &target)
+}
+
+func IsCasWriteUnknown(err error) bool {
+	var target *CasWriteUnknownError
+	return errors.As(err, &target)
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func statusWithDetails(t *testing.T, code codes.Code, detail *pb.Unavailable) error {
+	st, err := status.New(code, "Cannot achieve consistency level QUORUM").WithDetails(detail)
+	require.NoError(t, err)
+	return st.Err()
+}
+
+func TestExecuteQuery_TypedErrors(t *testing.T) {
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+			return nil, statusWithDetails(t, codes.Unavailable, &pb.Unavailable{
+				Consistency: pb.Consistency_QUORUM,
+				Required:    2,
+				Alive:       1,
+			})
+		},
+	})
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM ks.tbl"})
+	require.Error(t, err)
+	assert.Equal(t, "failed to execute query: rpc error: code = Unavailable desc = Cannot achieve consistency level QUORUM", err.Error())
+	assert.True(t, IsUnavailable(err))
+	assert.False(t, IsReadTimeout(err))
+	assert.Equal(t, codes.Unavailable, StatusCode(err))
+
+	var unavailable *UnavailableError
+	require.True(t, errors.As(err, &unavailable))
+	assert.Equal(t, pb.Consistency_QUORUM, unavailable.Consistency)
+	assert.Equal(t, int32(2), unavailable.Required)
+	assert.Equal(t, int32(1), unavailable.Alive)
+}
+
+func TestDecodeError(t *testing.T) {
+	st, err := status.New(codes.DeadlineExceeded, "timed out").WithDetails(&pb.WriteTimeout{
+		Consistency: pb.Consistency_LOCAL_QUORUM,
+		Received:    1,
+		BlockFor:    2,
+		WriteType:   "BATCH_LOG",
+	})
+	require.NoError(t, err)
+
+	var writeTimeout *WriteTimeoutError
+	require.True(t, errors.As(decodeError(st.Err()), &writeTimeout))
+	assert.Equal(t, WriteTypeBatchLog, writeTimeout.WriteType)
+	assert.Equal(t, codes.DeadlineExceeded, writeTimeout.GRPCStatus().Code())
+
+	st, err = status.New(codes.AlreadyExists, "exists").WithDetails(&pb.AlreadyExists{Keyspace: "ks", Table: "tbl"})
+	require.NoError(t, err)
+	assert.True(t, IsAlreadyExists(decodeError(st.Err())))
+
+	plain := status.Error(codes.Internal, "boom")
+	assert.Equal(t, plain, decodeError(plain))
+	assert.Equal(t, codes.Internal, StatusCode(plain))
+
+	assert.Equal(t, codes.Unknown, StatusCode(errors.New("boom")))
+	assert.Equal(t, codes.OK, StatusCode(nil))
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"google.golang.org/grpc"
+)
+
+var _ StargateQueryExecutor = (*StargateClient)(nil)
+
+// fakeStargate is a pb.StargateClient whose behaviour is supplied by each test.
+type fakeStargate struct {
+	executeQuery func(ctx context.Context, query *pb.Query) (*pb.Response, error)
+	executeBatch func(ctx context.Context, batch *pb.Batch) (*pb.Response, error)
+}
+
+func (f *fakeStargate) ExecuteQuery(ctx context.Context, in *pb.Query, _ ...grpc.CallOption) (*pb.Response, error) {
+	return f.executeQuery(ctx, in)
+}
+
+func (f *fakeStargate) ExecuteBatch(ctx context.Context, in *pb.Batch, _ ...grpc.CallOption) (*pb.Response, error) {
+	return f.executeBatch(ctx, in)
+}
+
+func newFakeClient(fake *fakeStargate, opts ...StargateClientOption) *StargateClient {
+	return newStargateClient(fake, opts...)
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// idempotence is what can be inferred about whether a statement is safe to
+// execute more than once from its CQL alone.
+type idempotence int
+
+const (
+	idempotenceUnknown idempotence = iota
+	idempotenceSafe
+	idempotenceUnsafe
+)
+
+var (
+	stringLiteral   = regexp.MustCompile(`'(?:[^']|'')*'`)
+	selectStatement = regexp.MustCompile(`(?i)^\s*SELECT\b`)
+	dmlStatement    = regexp.MustCompile(`(?i)^\s*(INSERT|UPDATE|DELETE|BEGIN)\b`)
+	// conditional matches the IF clause of a lightweight transaction.
+	conditional = regexp.MustCompile(`(?i)\bIF\b`)
+	// nonDeterministic matches functions returning a different value on
+	// every execution.
+	nonDeterministic = regexp.MustCompile(`(?i)\b(now|uuid|currenttimestamp|currentdate|currenttime|currenttimeuuid)\s*\(\s*\)`)
+	// selfAssignment matches `c = c + x` and `c = c - x`, capturing both
+	// column names, the operator and the start of the operand.
+	selfAssignment = regexp.MustCompile(`(\w+|"(?:[^"]|"")+")\s*=\s*(\w+|"(?:[^"]|"")+")\s*([+-])\s*(\S)`)
+	// compoundAssignment matches `c += x` and `c -= x`.
+	compoundAssignment = regexp.MustCompile(`(\w+|"(?:[^"]|"")+")\s*([+-])=\s*(\S)`)
+	// listPrepend matches `c = [x] + c`.
+	listPrepend = regexp.MustCompile(`=\s*\[[^\]]*\]\s*\+`)
+)
+
+// WithIdempotent returns a CallOption which marks the statement as safe, or
+// not, to execute more than once. This overrides both automatic detection and
+// the client's default.
+func WithIdempotent(idempotent bool) CallOption {
+	return func(o *callOptions) {
+		o.idempotent = &idempotent
+	}
+}
+
+// WithDefaultIdempotence returns a StargateClientOption which sets whether
+// statements are treated as idempotent when this cannot be inferred from their
+// CQL and no WithIdempotent option is given. The default is false.
+//
+// SELECT statements are always treated as idempotent, while counter updates,
+// list appends and prepends, lightweight transactions and statements calling
+// now() or uuid() never are.
+func WithDefaultIdempotence(idempotent bool) StargateClientOption {
+	return func(c *StargateClient) {
+		c.idempotent = idempotent
+	}
+}
+
+func (s *StargateClient) isIdempotent(o *callOptions, detected idempotence) bool {
+	if o.idempotent != nil {
+		return *o.idempotent
+	}
+
+	switch detected {
+	case idempotenceSafe:
+		return true
+	case idempotenceUnsafe:
+		return false
+	}
+	return s.idempotent
+}
+
+func queryIdempotence(query *pb.Query) idempotence {
+	return cqlIdempotence(query.GetCql())
+}
+
+func batchIdempotence(batch *pb.Batch) idempotence {
+	if batch.GetType() == pb.Batch_COUNTER {
+		return idempotenceUnsafe
+	}
+	for _, query := range batch.GetQueries() {
+		if cqlIdempotence(query.GetCql()) == idempotenceUnsafe {
+			return idempotenceUnsafe
+		}
+	}
+	return idempotenceUnknown
+}
+
+func cqlIdempotence(cql string) idempotence {
+	cql = stringLiteral.ReplaceAllString(cql, "''")
+
+	if selectStatement.MatchString(cql) {
+		return idempotenceSafe
+	}
+	if !dmlStatement.MatchString(cql) {
+		return idempotenceUnknown
+	}
+
+	if conditional.MatchString(cql) || nonDeterministic.MatchString(cql) || listPrepend.MatchString(cql) {
+		return idempotenceUnsafe
+	}
+	for _, m := range selfAssignment.FindAllStringSubmatch(cql, -1) {
+		if sameColumn(m[1], m[2]) && !isIdempotentOperand(m[3], m[4]) {
+			return idempotenceUnsafe
+		}
+	}
+	for _, m := range compoundAssignment.FindAllStringSubmatch(cql, -1) {
+		if !isIdempotentOperand(m[2], m[3]) {
+			return idempotenceUnsafe
+		}
+	}
+
+	return idempotenceUnknown
+}
+
+// isIdempotentOperand reports whether adding or removing operand is safe to
+// repeat. Adding to a set or map and removing from any collection are, while
+// counter increments and list appends are not. Bind markers could be either,
+// so they are treated as unsafe.
+func isIdempotentOperand(operator, operand string) bool {
+	switch operand {
+	case "{":
+		return true
+	case "[":
+		return operator == "-"
+	}
+	return false
+}
+
+func sameColumn(a, b string) bool {
+	if strings.HasPrefix(a, `"`) || strings.HasPrefix(b, `"`) {
+		return a == b
+	}
+	return strings.EqualFold(a, b)
+}
+
+// mayHaveBeenApplied reports whether a request that failed with err could have
+// been applied, making it unsafe to retry unless it is idempotent.
+func mayHaveBeenApplied(err error) bool {
+	return !IsUnavailable(err)
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCqlIdempotence(t *testing.T) {
+	tests := []struct {
+		cql      string
+		expected idempotence
+	}{
+		{"SELECT * FROM ks.tbl WHERE id = ?", idempotenceSafe},
+		{"select now() from system.local", idempotenceSafe},
+		{"INSERT INTO ks.tbl (id, v) VALUES (?, ?)", idempotenceUnknown},
+		{"INSERT INTO ks.tbl (id, v) VALUES (?, ?) IF NOT EXISTS", idempotenceUnsafe},
+		{"UPDATE ks.tbl SET v = ? WHERE id = ? IF v = ?", idempotenceUnsafe},
+		{"INSERT INTO ks.tbl (id, created) VALUES (now(), ?)", idempotenceUnsafe},
+		{"INSERT INTO ks.tbl (id, v) VALUES (uuid(), 'now()')", idempotenceUnsafe},
+		{"INSERT INTO ks.tbl (id, v) VALUES (?, 'if now()')", idempotenceUnknown},
+		{"UPDATE ks.counters SET hits = hits + 1 WHERE id = ?", idempotenceUnsafe},
+		{`UPDATE ks.counters SET "Hits" = "Hits" - ? WHERE id = ?`, idempotenceUnsafe},
+		{"UPDATE ks.counters SET hits += 1 WHERE id = ?", idempotenceUnsafe},
+		{"UPDATE ks.tbl SET items = items + ['a'] WHERE id = ?", idempotenceUnsafe},
+		{"UPDATE ks.tbl SET items = ['a'] + items WHERE id = ?", idempotenceUnsafe},
+		{"UPDATE ks.tbl SET items = items - ['a'] WHERE id = ?", idempotenceUnknown},
+		{"UPDATE ks.tbl SET tags = tags + {'a'} WHERE id = ?", idempotenceUnknown},
+		{"UPDATE ks.tbl SET total = other + 1 WHERE id = ?", idempotenceUnknown},
+		{"CREATE TABLE IF NOT EXISTS ks.tbl (id int PRIMARY KEY)", idempotenceUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cql, func(t *testing.T) {
+			assert.Equal(t, tt.expected, cqlIdempotence(tt.cql))
+		})
+	}
+}
+
+func TestBatchIdempotence(t *testing.T) {
+	assert.Equal(t, idempotenceUnsafe, batchIdempotence(&pb.Batch{Type: pb.Batch_COUNTER}))
+	assert.Equal(t, idempotenceUnsafe, batchIdempotence(&pb.Batch{Queries: []*pb.BatchQuery{
+		{Cql: "INSERT INTO ks.tbl (id, v) VALUES (?, ?)"},
+		{Cql: "UPDATE ks.tbl SET items = items + ? WHERE id = ?"},
+	}}))
+	assert.Equal(t, idempotenceUnknown, batchIdempotence(&pb.Batch{Queries: []*pb.BatchQuery{
+		{Cql: "INSERT INTO ks.tbl (id, v) VALUES (?, ?)"},
+	}}))
+}
+
+func TestRetry_Idempotence(t *testing.T) {
+	insert := &pb.Query{Cql: "INSERT INTO ks.tbl (id, v) VALUES (?, ?)"}
+	increment := &pb.Query{Cql: "UPDATE ks.counters SET hits = hits + 1 WHERE id = ?"}
+	transportErr := status.Error(codes.Unavailable, "gateway down")
+
+	tests := []struct {
+		name     string
+		query    *pb.Query
+		err      error
+		clientOp []StargateClientOption
+		callOpts []CallOption
+		attempts int
+	}{
+		{"unknown uses client default", insert, transportErr, nil, nil, 1},
+		{"client default idempotent", insert, transportErr, []StargateClientOption{WithDefaultIdempotence(true)}, nil, 2},
+		{"detected unsafe overrides default", increment, transportErr, []StargateClientOption{WithDefaultIdempotence(true)}, nil, 1},
+		{"call option overrides detection", increment, transportErr, nil, []CallOption{WithIdempotent(true)}, 2},
+		{"call option overrides default", insert, transportErr, []StargateClientOption{WithDefaultIdempotence(true)}, []CallOption{WithIdempotent(false)}, 1},
+		{"not applied is always retried", increment, statusWithDetails(t, codes.Unavailable, &pb.Unavailable{}), nil, nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received []*pb.Query
+			opts := append([]StargateClientOption{WithRetryPolicy(NewDefaultRetryPolicy())}, tt.clientOp...)
+			s := newFakeClient(failingStargate(1, tt.err, &received), opts...)
+
+			_, _ = s.ExecuteQueryWithOptions(tt.query, context.Background(), tt.callOpts...)
+			assert.Len(t, received, tt.attempts)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Iter iterates over every row returned by a query, transparently fetching
+// further pages using the paging state returned by Stargate. The page size is
+// taken from the query's parameters. An Iter is not safe for concurrent use.
+type Iter struct {
+	client *StargateClient
+	ctx    context.Context
+	cancel context.CancelFunc
+	query  *pb.Query
+
+	rows        *Rows
+	pagingState []byte
+	lastPage    bool
+	err         error
+
+	prefetch bool
+	pending  chan page
+}
+
+type page struct {
+	resultSet *pb.ResultSet
+	err       error
+}
+
+// IterOption is an option for an Iter.
+type IterOption func(*Iter)
+
+// WithPrefetch returns an IterOption which fetches the next page in the
+// background while the current one is being consumed.
+func WithPrefetch() IterOption {
+	return func(it *Iter) {
+		it.prefetch = true
+	}
+}
+
+// Iter executes query and returns an iterator over all of its rows. Pages are
+// fetched lazily as the iterator advances, each subject to the client's
+// timeout, and ctx applies to the iteration as a whole. Iteration resumes from
+// the query's paging state if one is set.
+func (s *StargateClient) Iter(ctx context.Context, query *pb.Query, opts ...IterOption) *Iter {
+	ctx, cancel := context.WithCancel(ctx)
+	it := &Iter{
+		client: s,
+		ctx:    ctx,
+		cancel: cancel,
+		query:  proto.Clone(query).(*pb.Query),
+		rows:   NewRows(nil),
+	}
+	if it.query.Parameters == nil {
+		it.query.Parameters = &pb.QueryParameters{}
+	}
+	it.pagingState = it.query.Parameters.GetPagingState().GetValue()
+
+	for _, opt := range opts {
+		opt(it)
+	}
+
+	return it
+}
+
+// Next advances to the next row, fetching the next page if the current one is
+// exhausted. It returns false when no rows remain or an error occurred, which
+// can be retrieved with Err.
+func (it *Iter) Next() bool {
+	for !it.rows.Next() {
+		if it.err != nil || it.lastPage {
+			return false
+		}
+		it.nextPage()
+	}
+	return true
+}
+
+// Scan copies the columns of the current row into dest, see Rows.Scan.
+func (it *Iter) Scan(dest ...interface{}) error {
+	return it.rows.Scan(dest...)
+}
+
+// ScanMap stores the current row in m, see Rows.ScanMap.
+func (it *Iter) ScanMap(m map[string]interface{}) error {
+	return it.rows.ScanMap(m)
+}
+
+// ScanStruct copies the current row into the struct pointed to by dest, see
+// ScanStruct.
+func (it *Iter) ScanStruct(dest interface{}) error {
+	return it.rows.ScanStruct(dest)
+}
+
+// Columns returns the column specs of the current page.
+func (it *Iter) Columns() []*pb.ColumnSpec {
+	return it.rows.Columns()
+}
+
+// PagingState returns the state needed to fetch the page following the
+// current one, or nil if the current page is the last. To resume a query
+// later without skipping rows, record the paging state once every row of the
+// current page has been consumed and set it on the query's parameters.
+func (it *Iter) PagingState() []byte {
+	if it.lastPage {
+		return nil
+	}
+	return it.pagingState
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *Iter) Err() error {
+	return it.err
+}
+
+// Close stops the iteration and cancels any page being prefetched.
+func (it *Iter) Close() error {
+	it.cancel()
+	it.rows = NewRows(nil)
+	it.lastPage = true
+	return nil
+}
+
+func (it *Iter) nextPage() {
+	var p page
+	if it.pending != nil {
+		p = <-it.pending
+		it.pending = nil
+	} else {
+		p = it.fetch(it.pagingState)
+	}
+
+	if p.err != nil {
+		it.err = p.err
+		return
+	}
+
+	it.rows = NewRows(p.resultSet)
+	it.pagingState = p.resultSet.GetPagingState().GetValue()
+	it.lastPage = len(it.pagingState) == 0
+
+	if it.prefetch && !it.lastPage {
+		it.pending = make(chan page, 1)
+		go func(state []byte, pending chan<- page) {
+			pending <- it.fetch(state)
+		}(it.pagingState, it.pending)
+	}
+}
+
+func (it *Iter) fetch(pagingState []byte) page {
+	query := proto.Clone(it.query).(*pb.Query)
+	if pagingState != nil {
+		query.Parameters.PagingState = wrapperspb.Bytes(pagingState)
+	}
+
+	ctx, cancel := context.WithTimeout(it.ctx, it.client.timeout)
+	defer cancel()
+
+	resp, err := it.client.ExecuteQueryWithContext(query, ctx)
+	if err != nil {
+		return page{err: err}
+	}
+
+	return page{resultSet: resp.GetResultSet()}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// pagedStargate serves rows 0 to total-1 in pages of the requested size,
+// using the index of the next row as the paging state.
+func pagedStargate(total int, calls *int32) *fakeStargate {
+	return &fakeStargate{
+		executeQuery: func(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+			atomic.AddInt32(calls, 1)
+
+			start := 0
+			if state := query.GetParameters().GetPagingState().GetValue(); state != nil {
+				start = int(state[0])
+			}
+			end := start + int(query.GetParameters().GetPageSize().GetValue())
+			if end > total {
+				end = total
+			}
+
+			resultSet := &pb.ResultSet{
+				Columns: []*pb.ColumnSpec{{Name: "n", Type: basicSpec(pb.TypeSpec_INT)}},
+			}
+			for i := start; i < end; i++ {
+				resultSet.Rows = append(resultSet.Rows, &pb.Row{Values: []*pb.Value{FromInt(int64(i))}})
+			}
+			if end < total {
+				resultSet.PagingState = wrapperspb.Bytes([]byte{byte(end)})
+			}
+
+			return &pb.Response{Result: &pb.Response_ResultSet{ResultSet: resultSet}}, nil
+		},
+	}
+}
+
+func pagedQuery(pageSize int32) *pb.Query {
+	return &pb.Query{
+		Cql:        "SELECT n FROM ks.numbers",
+		Parameters: &pb.QueryParameters{PageSize: wrapperspb.Int32(pageSize)},
+	}
+}
+
+func TestIter(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		t.Run(fmt.Sprintf("prefetch=%v", prefetch), func(t *testing.T) {
+			var calls int32
+			s := newFakeClient(pagedStargate(7, &calls))
+
+			var opts []IterOption
+			if prefetch {
+				opts = append(opts, WithPrefetch())
+			}
+			it := s.Iter(context.Background(), pagedQuery(3), opts...)
+
+			var got []int
+			for it.Next() {
+				var n int
+				require.NoError(t, it.Scan(&n))
+				got = append(got, n)
+			}
+			require.NoError(t, it.Err())
+			assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, got)
+			assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+			assert.Nil(t, it.PagingState())
+			assert.NoError(t, it.Close())
+		})
+	}
+}
+
+func TestIter_Resume(t *testing.T) {
+	var calls int32
+	s := newFakeClient(pagedStargate(7, &calls))
+
+	it := s.Iter(context.Background(), pagedQuery(3))
+	for i := 0; i < 3; i++ {
+		require.True(t, it.Next())
+	}
+	state := it.PagingState()
+	assert.Equal(t, []byte{3}, state)
+	require.NoError(t, it.Close())
+	assert.False(t, it.Next())
+
+	query := pagedQuery(3)
+	query.Parameters.PagingState = wrapperspb.Bytes(state)
+	it = s.Iter(context.Background(), query)
+	require.True(t, it.Next())
+	var n int
+	require.NoError(t, it.Scan(&n))
+	assert.Equal(t, 3, n)
+}
+
+func TestIter_Error(t *testing.T) {
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+			return nil, errors.New("boom")
+		},
+	})
+
+	it := s.Iter(context.Background(), pagedQuery(3))
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Err(), "failed to execute query: boom")
+}
@@ -0,0 +1,231 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// structFields caches the mapped fields of each struct type, keyed by
+// reflect.Type.
+var structFields sync.Map
+
+type fieldInfo struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+type structInfo struct {
+	fields []fieldInfo
+	byName map[string]*fieldInfo
+}
+
+// ScanStruct copies the values of row into the fields of the struct pointed
+// to by dest, matching each column name against the field's `cql` tag or its
+// lowercased name:
+//
+//	type User struct {
+//	    ID      uuid.UUID `cql:"id"`
+//	    Email   *string   `cql:"email"`
+//	    Ignored string    `cql:"-"`
+//	}
+//
+// Fields of embedded structs are promoted as they are by encoding/json and
+// columns without a matching field are ignored.
+func ScanStruct(columns []*pb.ColumnSpec, row *pb.Row, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("destination must be a non-nil pointer to a struct, got %T", dest)
+	}
+	if len(columns) != len(row.GetValues()) {
+		return fmt.Errorf("row has %d values but %d columns", len(row.GetValues()), len(columns))
+	}
+
+	info := cachedStructInfo(rv.Elem().Type())
+	for i, column := range columns {
+		field, ok := info.byName[column.GetName()]
+		if !ok {
+			continue
+		}
+		if err := decodeInto(row.GetValues()[i], column.GetType(), fieldByIndex(rv.Elem(), field.index)); err != nil {
+			return fmt.Errorf("error scanning column %s: %w", column.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// ScanStruct copies the current row into the struct pointed to by dest, see
+// ScanStruct for how columns are matched to fields.
+func (r *Rows) ScanStruct(dest interface{}) error {
+	row := r.Row()
+	if row == nil {
+		return errors.New("scan called without calling next")
+	}
+	return ScanStruct(r.Columns(), row, dest)
+}
+
+// StructValues encodes the mapped fields of src, a struct or pointer to one,
+// as named values suitable for an INSERT or UPDATE. Nil pointers are sent as
+// null. Zero fields tagged `cql:",omitempty"` and fields promoted through a nil
+// embedded struct pointer are sent as unset so the column is left untouched.
+func StructValues(src interface{}) (*pb.Values, error) {
+	rv := reflect.ValueOf(src)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("source must be a struct, got %T", src)
+	}
+
+	info := cachedStructInfo(rv.Type())
+	values := &pb.Values{
+		Values:     make([]*pb.Value, 0, len(info.fields)),
+		ValueNames: make([]string, 0, len(info.fields)),
+	}
+	for _, field := range info.fields {
+		fv, ok := fieldByIndexNoAlloc(rv, field.index)
+
+		var value *pb.Value
+		switch {
+		case !ok:
+			value = UnsetValue()
+		case field.omitEmpty && fv.IsZero():
+			value = UnsetValue()
+		default:
+			var err error
+			if value, err = encode(fv, nil); err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+		}
+
+		values.Values = append(values.Values, value)
+		values.ValueNames = append(values.ValueNames, field.name)
+	}
+
+	return values, nil
+}
+
+func cachedStructInfo(t reflect.Type) *structInfo {
+	if info, ok := structFields.Load(t); ok {
+		return info.(*structInfo)
+	}
+
+	info := &structInfo{byName: map[string]*fieldInfo{}}
+	collectFields(t, nil, info)
+	for i := range info.fields {
+		info.byName[info.fields[i].name] = &info.fields[i]
+	}
+
+	actual, _ := structFields.LoadOrStore(t, info)
+	return actual.(*structInfo)
+}
+
+// collectFields adds the fields of t before those of its embedded structs so
+// that, as with encoding/json, a field takes precedence over a promoted one
+// with the same name.
+func collectFields(t reflect.Type, index []int, info *structInfo) {
+	seen := map[string]bool{}
+	for _, f := range info.fields {
+		seen[f.name] = true
+	}
+
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+
+		_, hasTag := field.Tag.Lookup("cql")
+		if field.Anonymous && !hasTag {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				if field.PkgPath != "" {
+					// nil pointers to unexported structs cannot be allocated
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				field.Index = fieldIndex
+				embedded = append(embedded, field)
+				continue
+			}
+		}
+
+		name, omitEmpty, ok := parseTag(field)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		info.fields = append(info.fields, fieldInfo{name: name, index: fieldIndex, omitEmpty: omitEmpty})
+	}
+
+	for _, field := range embedded {
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		collectFields(ft, field.Index, info)
+	}
+}
+
+// parseTag returns the column name and options for a struct field, taken from
+// its `cql:"name,omitempty"` tag or else its lowercased name. Unexported fields
+// and fields tagged `cql:"-"` are skipped.
+func parseTag(field reflect.StructField) (name string, omitEmpty bool, ok bool) {
+	if field.PkgPath != "" {
+		return "", false, false
+	}
+
+	tag := field.Tag.Get("cql")
+	if tag == "-" {
+		return "", false, false
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, true
+}
+
+// fieldByIndex returns the field at index, allocating any nil embedded struct
+// pointers along the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// fieldByIndexNoAlloc is like fieldByIndex but reports false if a nil embedded
+// struct pointer is encountered.
+func fieldByIndexNoAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
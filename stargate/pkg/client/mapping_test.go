@@ -0,0 +1,86 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type AuditFields struct {
+	CreatedBy string `cql:"created_by"`
+	// Name is shadowed by user.Name.
+	Name string
+}
+
+type user struct {
+	*AuditFields
+	ID       uuid.UUID `cql:"id"`
+	Name     *string   `cql:"name"`
+	Age      int       `cql:"age,omitempty"`
+	Nickname string
+	Ignored  string `cql:"-"`
+	internal string
+}
+
+func TestScanStruct(t *testing.T) {
+	id := uuid.New()
+	columns := []*pb.ColumnSpec{
+		{Name: "id", Type: basicSpec(pb.TypeSpec_UUID)},
+		{Name: "name", Type: basicSpec(pb.TypeSpec_TEXT)},
+		{Name: "age", Type: basicSpec(pb.TypeSpec_INT)},
+		{Name: "nickname", Type: basicSpec(pb.TypeSpec_TEXT)},
+		{Name: "created_by", Type: basicSpec(pb.TypeSpec_TEXT)},
+		{Name: "unmapped", Type: basicSpec(pb.TypeSpec_TEXT)},
+	}
+	row := &pb.Row{Values: []*pb.Value{
+		FromUUID(id),
+		NullValue(),
+		FromInt(42),
+		FromString("al"),
+		FromString("admin"),
+		FromString("ignored"),
+	}}
+
+	var u user
+	require.NoError(t, ScanStruct(columns, row, &u))
+	assert.Equal(t, id, u.ID)
+	assert.Nil(t, u.Name)
+	assert.Equal(t, 42, u.Age)
+	assert.Equal(t, "al", u.Nickname)
+	require.NotNil(t, u.AuditFields)
+	assert.Equal(t, "admin", u.CreatedBy)
+	assert.Empty(t, u.AuditFields.Name)
+
+	err := ScanStruct(columns, row, u)
+	assert.EqualError(t, err, "destination must be a non-nil pointer to a struct, got client.user")
+
+	row.Values[2] = FromString("old")
+	err = ScanStruct(columns, row, &u)
+	assert.EqualError(t, err, "error scanning column age: cannot decode string into int")
+}
+
+func TestStructValues(t *testing.T) {
+	id := uuid.New()
+	name := "alpha"
+
+	values, err := StructValues(&user{ID: id, Name: &name, Nickname: "al"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "age", "nickname", "created_by"}, values.ValueNames)
+	assert.Equal(t, FromUUID(id).String(), values.Values[0].String())
+	assert.Equal(t, FromString("alpha").String(), values.Values[1].String())
+	assert.Equal(t, UnsetValue().String(), values.Values[2].String())
+	assert.Equal(t, FromString("al").String(), values.Values[3].String())
+	assert.Equal(t, UnsetValue().String(), values.Values[4].String())
+
+	values, err = StructValues(user{ID: id, Age: 3, AuditFields: &AuditFields{CreatedBy: "admin", Name: "audit"}})
+	require.NoError(t, err)
+	assert.Equal(t, NullValue().String(), values.Values[1].String())
+	assert.Equal(t, FromInt(3).String(), values.Values[2].String())
+	assert.Equal(t, FromString("admin").String(), values.Values[4].String())
+
+	_, err = StructValues("not a struct")
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// RequestKind is the kind of request a measurement is about.
+type RequestKind string
+
+const (
+	KindQuery RequestKind = "query"
+	KindBatch RequestKind = "batch"
+)
+
+// Metrics receives measurements of the requests made by a StargateClient. A
+// request covers every retry and speculative execution made for one call to
+// ExecuteQuery or ExecuteBatch. Implementations must be safe for concurrent
+// use.
+type Metrics interface {
+	// RequestStarted is called when a request starts.
+	RequestStarted(kind RequestKind)
+	// RequestFinished is called when a request completes, with errorType
+	// empty if it succeeded. Otherwise errorType is the name of the typed
+	// error for failures reported by Cassandra, such as UnavailableError or
+	// ReadTimeoutError, or else the gRPC status code.
+	RequestFinished(kind RequestKind, latency time.Duration, errorType string)
+	// RowsReturned is called when a request returns a result set.
+	RowsReturned(kind RequestKind, rows int)
+	// Retry is called before a request is retried.
+	Retry(kind RequestKind)
+	// SpeculativeExecution is called when a speculative execution starts.
+	SpeculativeExecution(kind RequestKind)
+	// ServerWarnings is called when a request returns warnings, with cql the
+	// statement, or statements of a batch, with literals replaced by "?".
+	ServerWarnings(kind RequestKind, cql string, warnings int)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) RequestStarted(RequestKind)                         {}
+func (nopMetrics) RequestFinished(RequestKind, time.Duration, string) {}
+func (nopMetrics) RowsReturned(RequestKind, int)                      {}
+func (nopMetrics) Retry(RequestKind)                                  {}
+func (nopMetrics) SpeculativeExecution(RequestKind)                   {}
+func (nopMetrics) ServerWarnings(RequestKind, string, int)            {}
+
+// WithMetrics returns a StargateClientOption which reports measurements of
+// every query and batch to metrics.
+func WithMetrics(metrics Metrics) StargateClientOption {
+	return func(c *StargateClient) {
+		c.metrics = metrics
+	}
+}
+
+// measure reports the start of a request and returns a func reporting its
+// outcome.
+func (s *StargateClient) measure(kind RequestKind) func(resp *pb.Response, err error) {
+	s.metrics.RequestStarted(kind)
+	start := time.Now()
+
+	return func(resp *pb.Response, err error) {
+		var errorType string
+		if err != nil {
+			errorType = errorClass(err)
+		}
+		s.metrics.RequestFinished(kind, time.Since(start), errorType)
+
+		if rs := resp.GetResultSet(); rs != nil {
+			s.metrics.RowsReturned(kind, len(rs.GetRows()))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordingMetrics is a Metrics keeping every call as a string.
+type recordingMetrics struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (m *recordingMetrics) record(format string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, fmt.Sprintf(format, args...))
+}
+
+func (m *recordingMetrics) RequestStarted(kind RequestKind) {
+	m.record("started %s", kind)
+}
+
+func (m *recordingMetrics) RequestFinished(kind RequestKind, _ time.Duration, errorType string) {
+	m.record("finished %s %q", kind, errorType)
+}
+
+func (m *recordingMetrics) RowsReturned(kind RequestKind, rows int) {
+	m.record("rows %s %d", kind, rows)
+}
+
+func (m *recordingMetrics) Retry(kind RequestKind) {
+	m.record("retry %s", kind)
+}
+
+func (m *recordingMetrics) SpeculativeExecution(kind RequestKind) {
+	m.record("speculative %s", kind)
+}
+
+func (m *recordingMetrics) ServerWarnings(kind RequestKind, cql string, warnings int) {
+	m.record("warnings %s %q %d", kind, cql, warnings)
+}
+
+func TestMetrics_Query(t *testing.T) {
+	metrics := &recordingMetrics{}
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(context.Context, *pb.Query) (*pb.Response, error) {
+			return &pb.Response{Result: &pb.Response_ResultSet{ResultSet: &pb.ResultSet{Rows: []*pb.Row{{}, {}}}}}, nil
+		},
+	}, WithMetrics(metrics))
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM t"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`started query`, `finished query ""`, `rows query 2`}, metrics.calls)
+}
+
+func TestMetrics_BatchRetriedThenFailed(t *testing.T) {
+	metrics := &recordingMetrics{}
+	s := newFakeClient(&fakeStargate{
+		executeBatch: func(context.Context, *pb.Batch) (*pb.Response, error) {
+			return nil, statusWithDetails(t, codes.Unavailable, &pb.Unavailable{Required: 2, Alive: 1})
+		},
+	}, WithMetrics(metrics), WithRetryPolicy(NewDefaultRetryPolicy()))
+
+	_, err := s.ExecuteBatch(&pb.Batch{Queries: []*pb.BatchQuery{{Cql: "INSERT INTO t (k) VALUES (1)"}}})
+	require.Error(t, err)
+	assert.Equal(t, []string{`started batch`, `retry batch`, `finished batch "UnavailableError"`}, metrics.calls)
+}
+
+func TestMetrics_SpeculativeExecution(t *testing.T) {
+	var calls int32
+	metrics := &recordingMetrics{}
+	s := newFakeClient(slowFirstStargate(&calls),
+		WithMetrics(metrics),
+		WithSpeculativeExecution(NewConstantSpeculativeExecutionPolicy(5*time.Millisecond, 2)),
+	)
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM t"})
+	require.NoError(t, err)
+	assert.Contains(t, metrics.calls, "speculative query")
+}
+
+func TestErrorClass(t *testing.T) {
+	assert.Equal(t, "DeadlineExceeded", errorClass(status.Error(codes.DeadlineExceeded, "timed out")))
+	assert.Equal(t, "Canceled", errorClass(context.Canceled))
+	assert.Equal(t, "Unknown", errorClass(fmt.Errorf("boom")))
+}
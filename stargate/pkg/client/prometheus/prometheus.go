@@ -0,0 +1,192 @@
+// Package prometheus reports the metrics of a StargateClient to Prometheus.
+package prometheus
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+)
+
+const defaultNamespace = "stargate_client"
+
+// DefaultRowBuckets are the buckets of the rows returned histogram.
+var DefaultRowBuckets = []float64{0, 1, 10, 100, 1000, 10000}
+
+// Metrics is a client.Metrics recording Prometheus metrics. It is a
+// prom.Collector, so it must be registered to be exported.
+type Metrics struct {
+	requests    *prom.CounterVec
+	errors      *prom.CounterVec
+	latency     *prom.HistogramVec
+	rows        *prom.HistogramVec
+	inFlight    *prom.GaugeVec
+	retries     *prom.CounterVec
+	speculative *prom.CounterVec
+	warnings    *prom.CounterVec
+
+	warningStatements bool
+}
+
+type options struct {
+	namespace      string
+	constLabels    prom.Labels
+	latencyBuckets []float64
+	rowBuckets     []float64
+
+	warningStatements bool
+}
+
+// Option is an option for Metrics.
+type Option func(*options)
+
+// WithNamespace returns an Option which sets the prefix of the metric names,
+// stargate_client by default.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithConstLabels returns an Option which adds labels with fixed values to
+// every metric, for instance to tell several clients apart.
+func WithConstLabels(labels prom.Labels) Option {
+	return func(o *options) {
+		o.constLabels = labels
+	}
+}
+
+// WithLatencyBuckets returns an Option which sets the buckets of the request
+// duration histogram in seconds, prom.DefBuckets by default.
+func WithLatencyBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.latencyBuckets = buckets
+	}
+}
+
+// WithRowBuckets returns an Option which sets the buckets of the rows
+// returned histogram, DefaultRowBuckets by default.
+func WithRowBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.rowBuckets = buckets
+	}
+}
+
+// WithWarningStatementLabel returns an Option which labels server warnings
+// with the statement that triggered them, with literals replaced by ?. The
+// label has one value per distinct statement, so it should only be enabled
+// for a bounded set of statements.
+func WithWarningStatementLabel() Option {
+	return func(o *options) {
+		o.warningStatements = true
+	}
+}
+
+// NewMetrics creates Metrics, which are passed to the client with
+// client.WithMetrics and registered with a prom.Registerer:
+//
+//	metrics := prometheus.NewMetrics()
+//	prom.MustRegister(metrics)
+//	stargateClient, err := client.NewStargateClientWithConn(conn, client.WithMetrics(metrics))
+func NewMetrics(opts ...Option) *Metrics {
+	o := &options{
+		namespace:      defaultNamespace,
+		latencyBuckets: prom.DefBuckets,
+		rowBuckets:     DefaultRowBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	counter := func(name, help string, labels ...string) *prom.CounterVec {
+		return prom.NewCounterVec(prom.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: o.constLabels,
+		}, labels)
+	}
+	histogram := func(name, help string, buckets []float64) *prom.HistogramVec {
+		return prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace:   o.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: o.constLabels,
+			Buckets:     buckets,
+		}, []string{"kind"})
+	}
+
+	m := &Metrics{
+		requests: counter("requests_total", "Queries and batches completed.", "kind"),
+		errors:   counter("errors_total", "Queries and batches that failed, by error type.", "kind", "type"),
+		latency: histogram("request_duration_seconds",
+			"Duration of queries and batches, including retries.", o.latencyBuckets),
+		rows: histogram("rows_returned", "Rows returned per query.", o.rowBuckets),
+		inFlight: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace:   o.namespace,
+			Name:        "requests_in_flight",
+			Help:        "Queries and batches in progress.",
+			ConstLabels: o.constLabels,
+		}, []string{"kind"}),
+		retries:     counter("retries_total", "Queries and batches retried.", "kind"),
+		speculative: counter("speculative_executions_total", "Speculative executions started.", "kind"),
+		warnings:    counter("server_warnings_total", "Warnings returned by the server.", "kind"),
+	}
+	if o.warningStatements {
+		m.warnings = counter("server_warnings_total",
+			"Warnings returned by the server, by statement with literals replaced by ?.", "kind", "statement")
+		m.warningStatements = true
+	}
+	return m
+}
+
+func (m *Metrics) collectors() []prom.Collector {
+	return []prom.Collector{m.requests, m.errors, m.latency, m.rows, m.inFlight, m.retries, m.speculative, m.warnings}
+}
+
+// Describe implements prom.Collector.
+func (m *Metrics) Describe(ch chan<- *prom.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prom.Collector.
+func (m *Metrics) Collect(ch chan<- prom.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *Metrics) RequestStarted(kind client.RequestKind) {
+	m.inFlight.WithLabelValues(string(kind)).Inc()
+}
+
+func (m *Metrics) RequestFinished(kind client.RequestKind, latency time.Duration, errorType string) {
+	m.inFlight.WithLabelValues(string(kind)).Dec()
+	m.requests.WithLabelValues(string(kind)).Inc()
+	m.latency.WithLabelValues(string(kind)).Observe(latency.Seconds())
+	if errorType != "" {
+		m.errors.WithLabelValues(string(kind), errorType).Inc()
+	}
+}
+
+func (m *Metrics) RowsReturned(kind client.RequestKind, rows int) {
+	m.rows.WithLabelValues(string(kind)).Observe(float64(rows))
+}
+
+func (m *Metrics) Retry(kind client.RequestKind) {
+	m.retries.WithLabelValues(string(kind)).Inc()
+}
+
+func (m *Metrics) SpeculativeExecution(kind client.RequestKind) {
+	m.speculative.WithLabelValues(string(kind)).Inc()
+}
+
+func (m *Metrics) ServerWarnings(kind client.RequestKind, cql string, warnings int) {
+	if !m.warningStatements {
+		m.warnings.WithLabelValues(string(kind)).Add(float64(warnings))
+		return
+	}
+	m.warnings.WithLabelValues(string(kind), cql).Add(float64(warnings))
+}
@@ -0,0 +1,90 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ client.Metrics = (*Metrics)(nil)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics(WithNamespace("test"), WithLatencyBuckets([]float64{0.1, 1}), WithRowBuckets([]float64{10}),
+		WithWarningStatementLabel())
+	registry := prom.NewPedanticRegistry()
+	require.NoError(t, registry.Register(m))
+
+	m.RequestStarted(client.KindQuery)
+	m.RequestStarted(client.KindQuery)
+	m.RequestFinished(client.KindQuery, 50*time.Millisecond, "")
+	m.RowsReturned(client.KindQuery, 5)
+	m.RequestStarted(client.KindBatch)
+	m.Retry(client.KindBatch)
+	m.RequestFinished(client.KindBatch, 2*time.Second, "WriteTimeoutError")
+	m.SpeculativeExecution(client.KindQuery)
+	m.ServerWarnings(client.KindBatch, "INSERT INTO t (k) VALUES (?)", 2)
+
+	expected := `
+# HELP test_errors_total Queries and batches that failed, by error type.
+# TYPE test_errors_total counter
+test_errors_total{kind="batch",type="WriteTimeoutError"} 1
+# HELP test_requests_in_flight Queries and batches in progress.
+# TYPE test_requests_in_flight gauge
+test_requests_in_flight{kind="batch"} 0
+test_requests_in_flight{kind="query"} 1
+# HELP test_requests_total Queries and batches completed.
+# TYPE test_requests_total counter
+test_requests_total{kind="batch"} 1
+test_requests_total{kind="query"} 1
+# HELP test_retries_total Queries and batches retried.
+# TYPE test_retries_total counter
+test_retries_total{kind="batch"} 1
+# HELP test_rows_returned Rows returned per query.
+# TYPE test_rows_returned histogram
+test_rows_returned_bucket{kind="query",le="10"} 1
+test_rows_returned_bucket{kind="query",le="+Inf"} 1
+test_rows_returned_sum{kind="query"} 5
+test_rows_returned_count{kind="query"} 1
+# HELP test_server_warnings_total Warnings returned by the server, by statement with literals replaced by ?.
+# TYPE test_server_warnings_total counter
+test_server_warnings_total{kind="batch",statement="INSERT INTO t (k) VALUES (?)"} 2
+# HELP test_speculative_executions_total Speculative executions started.
+# TYPE test_speculative_executions_total counter
+test_speculative_executions_total{kind="query"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_errors_total", "test_requests_in_flight", "test_requests_total", "test_retries_total",
+		"test_rows_returned", "test_server_warnings_total", "test_speculative_executions_total"))
+	assert.Equal(t, 2, testutil.CollectAndCount(m, "test_request_duration_seconds"))
+}
+
+func TestMetrics_ConstLabels(t *testing.T) {
+	m := NewMetrics(WithConstLabels(prom.Labels{"cluster": "east"}))
+	m.Retry(client.KindQuery)
+
+	expected := `
+# HELP stargate_client_retries_total Queries and batches retried.
+# TYPE stargate_client_retries_total counter
+stargate_client_retries_total{cluster="east",kind="query"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(m, strings.NewReader(expected), "stargate_client_retries_total"))
+}
+
+func TestMetrics_WarningsByKind(t *testing.T) {
+	m := NewMetrics()
+	m.ServerWarnings(client.KindQuery, "SELECT * FROM t WHERE k IN (?, ?)", 1)
+	m.ServerWarnings(client.KindQuery, "SELECT * FROM t WHERE k IN (?, ?, ?)", 1)
+
+	expected := `
+# HELP stargate_client_server_warnings_total Warnings returned by the server.
+# TYPE stargate_client_server_warnings_total counter
+stargate_client_server_warnings_total{kind="query"} 2
+`
+	assert.NoError(t, testutil.CollectAndCompare(m, strings.NewReader(expected), "stargate_client_server_warnings_total"))
+}
@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// LogLevel is the severity of a query log entry.
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	case LogError:
+		return "error"
+	}
+	return "LogLevel(" + strconv.Itoa(int(l)) + ")"
+}
+
+// QueryLogEntry describes a completed query or batch.
+type QueryLogEntry struct {
+	Kind RequestKind
+	// CQL is the statement, or the statements of a batch separated by "; ".
+	// Its literals are replaced by ? unless enabled with WithLiterals.
+	CQL string
+	// Values describes the bound values, in order across every statement of
+	// a batch, as returned by the ValueRedactor.
+	Values      []string
+	Keyspace    string
+	Consistency string
+	Duration    time.Duration
+	// Slow reports whether Duration exceeded the slow query threshold.
+	Slow bool
+	// Rows is the number of rows returned, if a result set was returned.
+	Rows int
+	// Err is the error the request failed with, and ErrorType its kind as
+	// reported to Metrics.
+	Err       error
+	ErrorType string
+}
+
+// Message returns a short description of the entry's outcome.
+func (e QueryLogEntry) Message() string {
+	switch {
+	case e.Err != nil:
+		return string(e.Kind) + " failed"
+	case e.Slow:
+		return "slow " + string(e.Kind)
+	}
+	return string(e.Kind) + " executed"
+}
+
+// QueryLogger records query log entries, typically by passing them to a
+// structured logger. See the querylog package for adapters for log/slog,
+// logrus and zap.
+type QueryLogger interface {
+	LogQuery(ctx context.Context, level LogLevel, entry QueryLogEntry)
+}
+
+// ValueRedactor returns how the bound value at position, named name if the
+// query uses named values, is logged.
+type ValueRedactor func(position int, name string, value *pb.Value) string
+
+// RedactValues is a ValueRedactor logging only the type and size of values,
+// such as string(12) or collection(3). This is the default.
+func RedactValues(_ int, _ string, value *pb.Value) string {
+	return valueShape(value)
+}
+
+// ShowValues is a ValueRedactor logging values in full.
+func ShowValues(_ int, _ string, value *pb.Value) string {
+	return formatValue(value)
+}
+
+// QueryLogOption is an option for query logging.
+type QueryLogOption func(*queryLog)
+
+type queryLog struct {
+	logger        QueryLogger
+	level         LogLevel
+	slowThreshold time.Duration
+	redact        ValueRedactor
+	showLiterals  bool
+}
+
+// WithQueryLogger returns a StargateClientOption which logs every query and
+// batch to logger at debug level, slow ones at warn level and failed ones at
+// error level.
+func WithQueryLogger(logger QueryLogger, opts ...QueryLogOption) StargateClientOption {
+	return func(c *StargateClient) {
+		c.queryLog = &queryLog{
+			logger: logger,
+			level:  LogDebug,
+			redact: RedactValues,
+		}
+		for _, opt := range opts {
+			opt(c.queryLog)
+		}
+	}
+}
+
+// WithQueryLogLevel returns a QueryLogOption which sets the level that
+// successful queries faster than the slow query threshold are logged at.
+func WithQueryLogLevel(level LogLevel) QueryLogOption {
+	return func(l *queryLog) {
+		l.level = level
+	}
+}
+
+// WithSlowQueryThreshold returns a QueryLogOption which logs queries taking
+// longer than threshold at warn level. Slow queries are not singled out if it
+// is zero, the default.
+func WithSlowQueryThreshold(threshold time.Duration) QueryLogOption {
+	return func(l *queryLog) {
+		l.slowThreshold = threshold
+	}
+}
+
+// WithValueRedactor returns a QueryLogOption which sets how bound values are
+// logged, RedactValues by default.
+func WithValueRedactor(redact ValueRedactor) QueryLogOption {
+	return func(l *queryLog) {
+		l.redact = redact
+	}
+}
+
+// WithLiterals returns a QueryLogOption which sets whether the literals of
+// statements are logged. They are replaced by ? by default.
+func WithLiterals(show bool) QueryLogOption {
+	return func(l *queryLog) {
+		l.showLiterals = show
+	}
+}
+
+func (s *StargateClient) startQueryLog(ctx context.Context, query *pb.Query) func(resp *pb.Response, err error) {
+	if s.queryLog == nil {
+		return func(*pb.Response, error) {}
+	}
+
+	params := query.GetParameters()
+	entry := QueryLogEntry{
+		Kind:        KindQuery,
+		CQL:         s.queryLog.cql(query.GetCql()),
+		Values:      s.queryLog.values(nil, query.GetValues()),
+		Keyspace:    params.GetKeyspace().GetValue(),
+		Consistency: consistencyName(params.GetConsistency()),
+	}
+	return s.queryLog.start(ctx, entry)
+}
+
+func (s *StargateClient) startBatchLog(ctx context.Context, batch *pb.Batch) func(resp *pb.Response, err error) {
+	if s.queryLog == nil {
+		return func(*pb.Response, error) {}
+	}
+
+	statements := make([]string, len(batch.GetQueries()))
+	var values []string
+	for i, query := range batch.GetQueries() {
+		statements[i] = s.queryLog.cql(query.GetCql())
+		values = s.queryLog.values(values, query.GetValues())
+	}
+
+	params := batch.GetParameters()
+	entry := QueryLogEntry{
+		Kind:        KindBatch,
+		CQL:         strings.Join(statements, "; "),
+		Values:      values,
+		Keyspace:    params.GetKeyspace().GetValue(),
+		Consistency: consistencyName(params.GetConsistency()),
+	}
+	return s.queryLog.start(ctx, entry)
+}
+
+func (l *queryLog) start(ctx context.Context, entry QueryLogEntry) func(resp *pb.Response, err error) {
+	start := time.Now()
+
+	return func(resp *pb.Response, err error) {
+		entry.Duration = time.Since(start)
+		entry.Slow = l.slowThreshold > 0 && entry.Duration > l.slowThreshold
+		entry.Rows = len(resp.GetResultSet().GetRows())
+
+		level := l.level
+		switch {
+		case err != nil:
+			entry.Err = err
+			entry.ErrorType = errorClass(err)
+			level = LogError
+		case entry.Slow && level < LogWarn:
+			level = LogWarn
+		}
+		l.logger.LogQuery(ctx, level, entry)
+	}
+}
+
+func (l *queryLog) cql(cql string) string {
+	if l.showLiterals {
+		return cql
+	}
+	return sanitizeCQL(cql)
+}
+
+func (l *queryLog) values(dst []string, values *pb.Values) []string {
+	names := values.GetValueNames()
+	for i, value := range values.GetValues() {
+		var name string
+		if i < len(names) {
+			name = names[i]
+		}
+		dst = append(dst, l.redact(len(dst), name, value))
+	}
+	return dst
+}
+
+func consistencyName(consistency *pb.ConsistencyValue) string {
+	if consistency == nil {
+		return ""
+	}
+	return consistency.GetValue().String()
+}
+
+func valueShape(value *pb.Value) string {
+	switch inner := value.GetInner().(type) {
+	case *pb.Value_Null_:
+		return "null"
+	case *pb.Value_Unset_:
+		return "unset"
+	case *pb.Value_Int:
+		return "int"
+	case *pb.Value_Float:
+		return "float"
+	case *pb.Value_Double:
+		return "double"
+	case *pb.Value_Boolean:
+		return "boolean"
+	case *pb.Value_String_:
+		return fmt.Sprintf("string(%d)", len(inner.String_))
+	case *pb.Value_Bytes:
+		return fmt.Sprintf("bytes(%d)", len(inner.Bytes))
+	case *pb.Value_Inet:
+		return "inet"
+	case *pb.Value_Uuid:
+		return "uuid"
+	case *pb.Value_Date:
+		return "date"
+	case *pb.Value_Time:
+		return "time"
+	case *pb.Value_Collection:
+		return fmt.Sprintf("collection(%d)", len(inner.Collection.GetElements()))
+	case *pb.Value_Udt:
+		return fmt.Sprintf("udt(%d)", len(inner.Udt.GetFields()))
+	case *pb.Value_Varint:
+		return "varint"
+	case *pb.Value_Decimal:
+		return "decimal"
+	}
+	return "unknown"
+}
+
+func formatValue(value *pb.Value) string {
+	switch inner := value.GetInner().(type) {
+	case *pb.Value_Int:
+		return strconv.FormatInt(inner.Int, 10)
+	case *pb.Value_Float:
+		return strconv.FormatFloat(float64(inner.Float), 'g', -1, 32)
+	case *pb.Value_Double:
+		return strconv.FormatFloat(inner.Double, 'g', -1, 64)
+	case *pb.Value_Boolean:
+		return strconv.FormatBool(inner.Boolean)
+	case *pb.Value_String_:
+		return strconv.Quote(inner.String_)
+	case *pb.Value_Bytes:
+		return fmt.Sprintf("0x%x", inner.Bytes)
+	case *pb.Value_Uuid:
+		if u, err := ToUUID(value); err == nil {
+			return u.String()
+		}
+	case *pb.Value_Collection:
+		elements := make([]string, len(inner.Collection.GetElements()))
+		for i, element := range inner.Collection.GetElements() {
+			elements[i] = formatValue(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+	return valueShape(value)
+}
@@ -0,0 +1,38 @@
+package querylog
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+)
+
+type logrusLogger struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogrus creates a client.QueryLogger writing to a logrus logger or entry.
+func NewLogrus(logger logrus.FieldLogger) client.QueryLogger {
+	return logrusLogger{logger: logger}
+}
+
+func (l logrusLogger) LogQuery(ctx context.Context, level client.LogLevel, entry client.QueryLogEntry) {
+	fs := fields(entry)
+	lf := make(logrus.Fields, len(fs))
+	for _, f := range fs {
+		lf[f.key] = f.value
+	}
+	l.logger.WithFields(lf).WithContext(ctx).Log(logrusLevel(level), entry.Message())
+}
+
+func logrusLevel(level client.LogLevel) logrus.Level {
+	switch level {
+	case client.LogDebug:
+		return logrus.DebugLevel
+	case client.LogInfo:
+		return logrus.InfoLevel
+	case client.LogWarn:
+		return logrus.WarnLevel
+	}
+	return logrus.ErrorLevel
+}
@@ -0,0 +1,37 @@
+// Package querylog adapts structured loggers to client.QueryLogger.
+package querylog
+
+import (
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+)
+
+// field is a key and value logged for a query.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// fields returns the fields logged for entry, omitting those that are not set.
+func fields(entry client.QueryLogEntry) []field {
+	fs := []field{
+		{"kind", string(entry.Kind)},
+		{"cql", entry.CQL},
+		{"duration", entry.Duration},
+	}
+	if len(entry.Values) > 0 {
+		fs = append(fs, field{"values", entry.Values})
+	}
+	if entry.Keyspace != "" {
+		fs = append(fs, field{"keyspace", entry.Keyspace})
+	}
+	if entry.Consistency != "" {
+		fs = append(fs, field{"consistency", entry.Consistency})
+	}
+	if entry.Err != nil {
+		return append(fs, field{"error", entry.Err.Error()}, field{"error_type", entry.ErrorType})
+	}
+	if entry.Slow {
+		fs = append(fs, field{"slow", true})
+	}
+	return append(fs, field{"rows", entry.Rows})
+}
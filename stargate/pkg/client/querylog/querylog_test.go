@@ -0,0 +1,93 @@
+package querylog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var (
+	executed = client.QueryLogEntry{
+		Kind:        client.KindQuery,
+		CQL:         "SELECT * FROM users WHERE name = ?",
+		Values:      []string{"string(5)"},
+		Keyspace:    "app",
+		Consistency: "LOCAL_QUORUM",
+		Duration:    3 * time.Millisecond,
+		Rows:        1,
+	}
+	failed = client.QueryLogEntry{
+		Kind:      client.KindBatch,
+		CQL:       "INSERT INTO t (k) VALUES (1)",
+		Duration:  time.Second,
+		Err:       errors.New("write timeout"),
+		ErrorType: "WriteTimeoutError",
+	}
+)
+
+func TestFields(t *testing.T) {
+	assert.Equal(t, []field{
+		{"kind", "query"},
+		{"cql", "SELECT * FROM users WHERE name = ?"},
+		{"duration", 3 * time.Millisecond},
+		{"values", []string{"string(5)"}},
+		{"keyspace", "app"},
+		{"consistency", "LOCAL_QUORUM"},
+		{"rows", 1},
+	}, fields(executed))
+
+	assert.Equal(t, []field{
+		{"kind", "batch"},
+		{"cql", "INSERT INTO t (k) VALUES (1)"},
+		{"duration", time.Second},
+		{"error", "write timeout"},
+		{"error_type", "WriteTimeoutError"},
+	}, fields(failed))
+}
+
+func TestLogrus(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.InfoLevel)
+	ql := NewLogrus(logger)
+
+	ql.LogQuery(context.Background(), client.LogDebug, executed)
+	ql.LogQuery(context.Background(), client.LogError, failed)
+
+	require.Len(t, hook.Entries, 1)
+	entry := hook.LastEntry()
+	assert.Equal(t, logrus.ErrorLevel, entry.Level)
+	assert.Equal(t, "batch failed", entry.Message)
+	assert.Equal(t, "WriteTimeoutError", entry.Data["error_type"])
+	assert.Equal(t, "INSERT INTO t (k) VALUES (1)", entry.Data["cql"])
+}
+
+func TestZap(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ql := NewZap(zap.New(core))
+
+	ql.LogQuery(context.Background(), client.LogWarn, executed)
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	assert.Equal(t, "query executed", entry.Message)
+	assert.Equal(t, map[string]interface{}{
+		"kind":        "query",
+		"cql":         "SELECT * FROM users WHERE name = ?",
+		"duration":    3 * time.Millisecond,
+		"values":      []interface{}{"string(5)"},
+		"keyspace":    "app",
+		"consistency": "LOCAL_QUORUM",
+		"rows":        int64(1),
+	}, entry.ContextMap())
+}
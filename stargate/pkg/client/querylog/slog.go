@@ -0,0 +1,45 @@
+//go:build go1.21
+
+package querylog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+)
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlog creates a client.QueryLogger writing to a log/slog logger.
+func NewSlog(logger *slog.Logger) client.QueryLogger {
+	return slogLogger{logger: logger}
+}
+
+func (l slogLogger) LogQuery(ctx context.Context, level client.LogLevel, entry client.QueryLogEntry) {
+	sl := slogLevel(level)
+	if !l.logger.Enabled(ctx, sl) {
+		return
+	}
+
+	fs := fields(entry)
+	attrs := make([]slog.Attr, len(fs))
+	for i, f := range fs {
+		attrs[i] = slog.Any(f.key, f.value)
+	}
+	l.logger.LogAttrs(ctx, sl, entry.Message(), attrs...)
+}
+
+func slogLevel(level client.LogLevel) slog.Level {
+	switch level {
+	case client.LogDebug:
+		return slog.LevelDebug
+	case client.LogInfo:
+		return slog.LevelInfo
+	case client.LogWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
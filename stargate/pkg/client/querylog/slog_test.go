@@ -0,0 +1,31 @@
+//go:build go1.21
+
+package querylog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+	ql := NewSlog(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	ql.LogQuery(context.Background(), client.LogDebug, executed)
+	assert.Zero(t, buf.Len())
+
+	ql.LogQuery(context.Background(), client.LogError, failed)
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "batch failed", record["msg"])
+	assert.Equal(t, "write timeout", record["error"])
+	assert.Equal(t, "WriteTimeoutError", record["error_type"])
+}
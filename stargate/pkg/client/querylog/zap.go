@@ -0,0 +1,44 @@
+package querylog
+
+import (
+	"context"
+
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type zapLogger struct {
+	logger *zap.Logger
+}
+
+// NewZap creates a client.QueryLogger writing to a zap logger.
+func NewZap(logger *zap.Logger) client.QueryLogger {
+	return zapLogger{logger: logger}
+}
+
+func (l zapLogger) LogQuery(_ context.Context, level client.LogLevel, entry client.QueryLogEntry) {
+	ce := l.logger.Check(zapLevel(level), entry.Message())
+	if ce == nil {
+		return
+	}
+
+	fs := fields(entry)
+	zfs := make([]zap.Field, len(fs))
+	for i, f := range fs {
+		zfs[i] = zap.Any(f.key, f.value)
+	}
+	ce.Write(zfs...)
+}
+
+func zapLevel(level client.LogLevel) zapcore.Level {
+	switch level {
+	case client.LogDebug:
+		return zapcore.DebugLevel
+	case client.LogInfo:
+		return zapcore.InfoLevel
+	case client.LogWarn:
+		return zapcore.WarnLevel
+	}
+	return zapcore.ErrorLevel
+}
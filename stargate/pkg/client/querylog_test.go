@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type loggedQuery struct {
+	level LogLevel
+	entry QueryLogEntry
+}
+
+type recordingQueryLogger struct {
+	mu      sync.Mutex
+	entries []loggedQuery
+}
+
+func (l *recordingQueryLogger) LogQuery(_ context.Context, level LogLevel, entry QueryLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, loggedQuery{level: level, entry: entry})
+}
+
+func TestQueryLog_Query(t *testing.T) {
+	logger := &recordingQueryLogger{}
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(context.Context, *pb.Query) (*pb.Response, error) {
+			return &pb.Response{Result: &pb.Response_ResultSet{ResultSet: &pb.ResultSet{Rows: []*pb.Row{{}}}}}, nil
+		},
+	}, WithQueryLogger(logger))
+
+	_, err := s.ExecuteQuery(&pb.Query{
+		Cql: "SELECT * FROM users WHERE name = ? AND tags CONTAINS ?",
+		Values: &pb.Values{Values: []*pb.Value{
+			{Inner: &pb.Value_String_{String_: "alice"}},
+			{Inner: &pb.Value_Collection{Collection: &pb.Collection{Elements: []*pb.Value{{}, {}}}}},
+		}},
+		Parameters: &pb.QueryParameters{
+			Keyspace:    wrapperspb.String("app"),
+			Consistency: &pb.ConsistencyValue{Value: pb.Consistency_LOCAL_ONE},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, logger.entries, 1)
+	logged := logger.entries[0]
+	assert.Equal(t, LogDebug, logged.level)
+	assert.Equal(t, KindQuery, logged.entry.Kind)
+	assert.Equal(t, "SELECT * FROM users WHERE name = ? AND tags CONTAINS ?", logged.entry.CQL)
+	assert.Equal(t, []string{"string(5)", "collection(2)"}, logged.entry.Values)
+	assert.Equal(t, "app", logged.entry.Keyspace)
+	assert.Equal(t, "LOCAL_ONE", logged.entry.Consistency)
+	assert.Equal(t, 1, logged.entry.Rows)
+	assert.False(t, logged.entry.Slow)
+	assert.Equal(t, "query executed", logged.entry.Message())
+}
+
+func TestQueryLog_SlowAndFailed(t *testing.T) {
+	logger := &recordingQueryLogger{}
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(context.Context, *pb.Query) (*pb.Response, error) {
+			time.Sleep(20 * time.Millisecond)
+			return &pb.Response{}, nil
+		},
+		executeBatch: func(context.Context, *pb.Batch) (*pb.Response, error) {
+			return nil, statusWithDetails(t, codes.Unavailable, &pb.Unavailable{Required: 2, Alive: 1})
+		},
+	}, WithQueryLogger(logger, WithSlowQueryThreshold(10*time.Millisecond), WithQueryLogLevel(LogInfo)))
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM t"})
+	require.NoError(t, err)
+	_, err = s.ExecuteBatch(&pb.Batch{Queries: []*pb.BatchQuery{
+		{Cql: "INSERT INTO t (k) VALUES (?)", Values: &pb.Values{Values: []*pb.Value{{Inner: &pb.Value_Int{Int: 1}}}}},
+		{Cql: "DELETE FROM t WHERE k = ?", Values: &pb.Values{Values: []*pb.Value{{Inner: &pb.Value_Int{Int: 2}}}}},
+	}})
+	require.Error(t, err)
+
+	require.Len(t, logger.entries, 2)
+	slow := logger.entries[0]
+	assert.Equal(t, LogWarn, slow.level)
+	assert.True(t, slow.entry.Slow)
+	assert.Equal(t, "slow query", slow.entry.Message())
+
+	failed := logger.entries[1]
+	assert.Equal(t, LogError, failed.level)
+	assert.Equal(t, "INSERT INTO t (k) VALUES (?); DELETE FROM t WHERE k = ?", failed.entry.CQL)
+	assert.Equal(t, []string{"int", "int"}, failed.entry.Values)
+	assert.Equal(t, "UnavailableError", failed.entry.ErrorType)
+	assert.Error(t, failed.entry.Err)
+	assert.Equal(t, "batch failed", failed.entry.Message())
+}
+
+func TestQueryLog_ValueRedactor(t *testing.T) {
+	logger := &recordingQueryLogger{}
+	redact := func(position int, name string, value *pb.Value) string {
+		if name == "password" {
+			return "***"
+		}
+		return ShowValues(position, name, value)
+	}
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(context.Context, *pb.Query) (*pb.Response, error) {
+			return nil, errors.New("boom")
+		},
+	}, WithQueryLogger(logger, WithValueRedactor(redact)))
+
+	_, _ = s.ExecuteQuery(&pb.Query{
+		Cql: "UPDATE users SET password = :password WHERE name = :name",
+		Values: &pb.Values{
+			Values: []*pb.Value{
+				{Inner: &pb.Value_String_{String_: "hunter2"}},
+				{Inner: &pb.Value_String_{String_: "alice"}},
+			},
+			ValueNames: []string{"password", "name"},
+		},
+	})
+
+	require.Len(t, logger.entries, 1)
+	assert.Equal(t, []string{"***", `"alice"`}, logger.entries[0].entry.Values)
+}
+
+func TestQueryLog_Literals(t *testing.T) {
+	const cql = "CREATE ROLE alice WITH PASSWORD = 'secret' AND LOGIN = true"
+	tests := []struct {
+		name string
+		opts []QueryLogOption
+		cql  string
+	}{
+		{"redacted by default", nil, "CREATE ROLE alice WITH PASSWORD = ? AND LOGIN = true"},
+		{"values shown", []QueryLogOption{WithValueRedactor(ShowValues)}, "CREATE ROLE alice WITH PASSWORD = ? AND LOGIN = true"},
+		{"literals shown", []QueryLogOption{WithLiterals(true)}, cql},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingQueryLogger{}
+			s := newFakeClient(&fakeStargate{
+				executeQuery: func(context.Context, *pb.Query) (*pb.Response, error) {
+					return &pb.Response{}, nil
+				},
+			}, WithQueryLogger(logger, tt.opts...))
+
+			_, err := s.ExecuteQuery(&pb.Query{Cql: cql})
+			require.NoError(t, err)
+			require.Len(t, logger.entries, 1)
+			assert.Equal(t, tt.cql, logger.entries[0].entry.CQL)
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value *pb.Value
+		shape string
+		full  string
+	}{
+		{&pb.Value{Inner: &pb.Value_Null_{}}, "null", "null"},
+		{&pb.Value{Inner: &pb.Value_Double{Double: 1.5}}, "double", "1.5"},
+		{&pb.Value{Inner: &pb.Value_Bytes{Bytes: []byte{0xca, 0xfe}}}, "bytes(2)", "0xcafe"},
+		{&pb.Value{Inner: &pb.Value_Collection{Collection: &pb.Collection{Elements: []*pb.Value{
+			{Inner: &pb.Value_Int{Int: 1}}, {Inner: &pb.Value_Boolean{Boolean: true}},
+		}}}}, "collection(2)", "[1, true]"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.shape, RedactValues(0, "", tt.value))
+		assert.Equal(t, tt.full, ShowValues(0, "", tt.value))
+	}
+}
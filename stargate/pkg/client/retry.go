@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"google.golang.org/grpc/codes"
+)
+
+// RetryAction is the action a RetryPolicy takes after a failed attempt.
+type RetryAction int
+
+const (
+	// Rethrow returns the error to the caller.
+	Rethrow RetryAction = iota
+	// Retry executes the request again unchanged.
+	Retry
+	// RetryWithConsistency executes the request again using the consistency
+	// level in RetryDecision.Consistency.
+	RetryWithConsistency
+)
+
+// RetryDecision is returned by a RetryPolicy after a failed attempt.
+type RetryDecision struct {
+	Action RetryAction
+	// Consistency is the consistency level to use when Action is
+	// RetryWithConsistency.
+	Consistency pb.Consistency
+	// Delay is how long to wait before the next attempt.
+	Delay time.Duration
+}
+
+// RetryInfo describes the attempt that failed.
+type RetryInfo struct {
+	// Attempt is the number of attempts made so far, starting at 1.
+	Attempt int
+	// Consistency is the consistency level used by the failed attempt, or nil
+	// if the server default was used.
+	Consistency *pb.ConsistencyValue
+	// Idempotent reports whether the request is safe to execute more than
+	// once. Policies are only consulted for a non-idempotent request when the
+	// error shows it was not applied.
+	Idempotent bool
+}
+
+// RetryPolicy decides whether a failed query or batch should be executed
+// again. The error passed to OnError has already been decoded, so it can be
+// inspected with errors.As and the IsXxx functions.
+type RetryPolicy interface {
+	OnError(info RetryInfo, err error) RetryDecision
+}
+
+var rethrow = RetryDecision{Action: Rethrow}
+
+type fallthroughRetryPolicy struct{}
+
+// NewFallthroughRetryPolicy creates a RetryPolicy that never retries. This is
+// the policy used by a StargateClient unless WithRetryPolicy is provided.
+func NewFallthroughRetryPolicy() RetryPolicy {
+	return fallthroughRetryPolicy{}
+}
+
+func (fallthroughRetryPolicy) OnError(RetryInfo, error) RetryDecision {
+	return rethrow
+}
+
+type defaultRetryPolicy struct{}
+
+// NewDefaultRetryPolicy creates a RetryPolicy that retries once, and only
+// when doing so is likely to succeed:
+//
+//   - a read timeout where enough replicas responded but the data was not
+//     retrieved
+//   - a write timeout while writing to the batch log
+//   - an unavailable error, or the gateway itself being unavailable
+func NewDefaultRetryPolicy() RetryPolicy {
+	return defaultRetryPolicy{}
+}
+
+func (defaultRetryPolicy) OnError(info RetryInfo, err error) RetryDecision {
+	if info.Attempt > 1 {
+		return rethrow
+	}
+
+	var readTimeout *ReadTimeoutError
+	var writeTimeout *WriteTimeoutError
+	switch {
+	case errors.As(err, &readTimeout):
+		if readTimeout.Received >= readTimeout.BlockFor && !readTimeout.DataPresent {
+			return RetryDecision{Action: Retry}
+		}
+	case errors.As(err, &writeTimeout):
+		if writeTimeout.WriteType == WriteTypeBatchLog {
+			return RetryDecision{Action: Retry}
+		}
+	case IsUnavailable(err), StatusCode(err) == codes.Unavailable:
+		return RetryDecision{Action: Retry}
+	}
+
+	return rethrow
+}
+
+type exponentialBackoffRetryPolicy struct {
+	maxRetries int
+	minDelay   time.Duration
+	maxDelay   time.Duration
+}
+
+// NewExponentialBackoffRetryPolicy creates a RetryPolicy that retries timeouts
+// and unavailable errors up to maxRetries times. The delay before each retry
+// is chosen at random between zero and minDelay doubled for every previous
+// attempt, capped at maxDelay.
+func NewExponentialBackoffRetryPolicy(maxRetries int, minDelay, maxDelay time.Duration) RetryPolicy {
+	return exponentialBackoffRetryPolicy{
+		maxRetries: maxRetries,
+		minDelay:   minDelay,
+		maxDelay:   maxDelay,
+	}
+}
+
+func (p exponentialBackoffRetryPolicy) OnError(info RetryInfo, err error) RetryDecision {
+	if info.Attempt > p.maxRetries || !isRetryable(err) {
+		return rethrow
+	}
+
+	return RetryDecision{Action: Retry, Delay: backoff(info.Attempt, p.minDelay, p.maxDelay)}
+}
+
+// isRetryable reports whether err is a transient failure that may succeed if
+// the request is executed again.
+func isRetryable(err error) bool {
+	switch {
+	case IsUnavailable(err), IsReadTimeout(err), IsWriteTimeout(err):
+		return true
+	}
+	return StatusCode(err) == codes.Unavailable
+}
+
+func backoff(attempt int, minDelay, maxDelay time.Duration) time.Duration {
+	ceiling := minDelay
+	for i := 1; i < attempt && ceiling < maxDelay; i++ {
+		ceiling *= 2
+	}
+	if ceiling > maxDelay {
+		ceiling = maxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// WithRetryPolicy returns a StargateClientOption which sets the policy used to
+// retry failed queries and batches.
+func WithRetryPolicy(policy RetryPolicy) StargateClientOption {
+	return func(c *StargateClient) {
+		c.retryPolicy = policy
+	}
+}
+
+// executeWithRetries calls attempt until it succeeds or the retry policy
+// gives up. Non-idempotent requests are only retried when the error shows they
+// were not applied. The consistency override passed to attempt is nil unless
+// the policy asked for a different consistency level.
+func (s *StargateClient) executeWithRetries(
+	ctx context.Context,
+	kind RequestKind,
+	consistency *pb.ConsistencyValue,
+	idempotent bool,
+	attempt func(ctx context.Context, consistency *pb.ConsistencyValue) (*pb.Response, error),
+) (*pb.Response, error) {
+	var override *pb.ConsistencyValue
+	for i := 1; ; i++ {
+		resp, err := attempt(ctx, override)
+		if err == nil {
+			return resp, nil
+		}
+
+		err = decodeError(err)
+		if ctx.Err() != nil || (!idempotent && mayHaveBeenApplied(err)) {
+			return nil, err
+		}
+
+		decision := s.retryPolicy.OnError(RetryInfo{
+			Attempt:     i,
+			Consistency: consistency,
+			Idempotent:  idempotent,
+		}, err)
+		switch decision.Action {
+		case Retry:
+		case RetryWithConsistency:
+			override = &pb.ConsistencyValue{Value: decision.Consistency}
+			consistency = override
+		default:
+			return nil, err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < decision.Delay {
+			return nil, err
+		}
+		if decision.Delay > 0 {
+			timer := time.NewTimer(decision.Delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, err
+			case <-timer.C:
+			}
+		}
+		s.metrics.Retry(kind)
+	}
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+)
+
+// failingStargate fails the first failures queries with err and records the
+// queries it receives.
+func failingStargate(failures int, err error, received *[]*pb.Query) *fakeStargate {
+	return &fakeStargate{
+		executeQuery: func(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+			*received = append(*received, query)
+			if len(*received) <= failures {
+				return nil, err
+			}
+			return &pb.Response{}, nil
+		},
+	}
+}
+
+func TestRetry_FallthroughByDefault(t *testing.T) {
+	var received []*pb.Query
+	s := newFakeClient(failingStargate(1, status.Error(codes.Unavailable, "gateway down"), &received))
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM ks.tbl"})
+	assert.Error(t, err)
+	assert.Len(t, received, 1)
+}
+
+func TestRetry_DefaultPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		detail   protoiface.MessageV1
+		attempts int
+	}{
+		{"unavailable", &pb.Unavailable{Required: 2, Alive: 1}, 2},
+		{"read timeout without data", &pb.ReadTimeout{Received: 2, BlockFor: 2}, 2},
+		{"read timeout with data", &pb.ReadTimeout{Received: 2, BlockFor: 2, DataPresent: true}, 1},
+		{"read timeout too few replicas", &pb.ReadTimeout{Received: 1, BlockFor: 2}, 1},
+		{"write timeout on batch log", &pb.WriteTimeout{WriteType: "BATCH_LOG"}, 2},
+		{"write timeout on simple write", &pb.WriteTimeout{WriteType: "SIMPLE"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := status.New(codes.Unavailable, tt.name).WithDetails(tt.detail)
+			require.NoError(t, err)
+
+			var received []*pb.Query
+			s := newFakeClient(failingStargate(2, st.Err(), &received), WithRetryPolicy(NewDefaultRetryPolicy()))
+
+			_, err = s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM ks.tbl"})
+			assert.Error(t, err)
+			assert.Len(t, received, tt.attempts)
+		})
+	}
+}
+
+type downgradingPolicy struct{}
+
+func (downgradingPolicy) OnError(info RetryInfo, err error) RetryDecision {
+	var unavailable *UnavailableError
+	if info.Attempt == 1 && errors.As(err, &unavailable) {
+		return RetryDecision{Action: RetryWithConsistency, Consistency: pb.Consistency_ONE}
+	}
+	return RetryDecision{Action: Rethrow}
+}
+
+func TestRetry_DowngradeConsistency(t *testing.T) {
+	st, err := status.New(codes.Unavailable, "unavailable").WithDetails(&pb.Unavailable{Required: 2, Alive: 1})
+	require.NoError(t, err)
+
+	var received []*pb.Query
+	s := newFakeClient(failingStargate(1, st.Err(), &received), WithRetryPolicy(downgradingPolicy{}))
+
+	query := &pb.Query{
+		Cql:        "SELECT * FROM ks.tbl",
+		Parameters: &pb.QueryParameters{Consistency: &pb.ConsistencyValue{Value: pb.Consistency_QUORUM}},
+	}
+	_, err = s.ExecuteQuery(query)
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+	assert.Equal(t, pb.Consistency_QUORUM, received[0].GetParameters().GetConsistency().GetValue())
+	assert.Equal(t, pb.Consistency_ONE, received[1].GetParameters().GetConsistency().GetValue())
+	assert.Equal(t, pb.Consistency_QUORUM, query.GetParameters().GetConsistency().GetValue())
+}
+
+func TestRetry_ExponentialBackoff(t *testing.T) {
+	var received []*pb.Query
+	s := newFakeClient(
+		failingStargate(10, status.Error(codes.Unavailable, "gateway down"), &received),
+		WithRetryPolicy(NewExponentialBackoffRetryPolicy(3, time.Millisecond, 4*time.Millisecond)),
+	)
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM ks.tbl"})
+	assert.Error(t, err)
+	assert.Len(t, received, 4)
+
+	received = nil
+	s = newFakeClient(
+		failingStargate(1, status.Error(codes.InvalidArgument, "bad query"), &received),
+		WithRetryPolicy(NewExponentialBackoffRetryPolicy(3, time.Millisecond, 4*time.Millisecond)),
+	)
+	_, err = s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM ks.tbl"})
+	assert.Error(t, err)
+	assert.Len(t, received, 1)
+}
+
+func TestRetry_HonorsDeadline(t *testing.T) {
+	var received []*pb.Query
+	s := newFakeClient(
+		failingStargate(10, status.Error(codes.Unavailable, "gateway down"), &received),
+		WithRetryPolicy(NewExponentialBackoffRetryPolicy(10, time.Hour, time.Hour)),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := s.ExecuteQueryWithContext(&pb.Query{Cql: "SELECT * FROM ks.tbl"}, ctx)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 1; attempt < 10; attempt++ {
+		delay := backoff(attempt, 10*time.Millisecond, 50*time.Millisecond)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, 50*time.Millisecond)
+	}
+	assert.Equal(t, time.Duration(0), backoff(1, 0, 0))
+}
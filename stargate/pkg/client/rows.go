@@ -0,0 +1,107 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// Rows is a cursor over the rows of a pb.ResultSet. It starts before the first
+// row, so Next must be called before the first call to Scan:
+//
+//	rows := client.NewRows(response.GetResultSet())
+//	for rows.Next() {
+//	    var key string
+//	    if err := rows.Scan(&key); err != nil {
+//	        return err
+//	    }
+//	}
+type Rows struct {
+	resultSet *pb.ResultSet
+	pos       int
+}
+
+// NewRows creates a cursor over the rows of resultSet, which may be nil.
+func NewRows(resultSet *pb.ResultSet) *Rows {
+	return &Rows{
+		resultSet: resultSet,
+		pos:       -1,
+	}
+}
+
+// Next advances to the next row, returning false when no rows remain.
+func (r *Rows) Next() bool {
+	if r.pos < len(r.resultSet.GetRows()) {
+		r.pos++
+	}
+	return r.pos < len(r.resultSet.GetRows())
+}
+
+// Columns returns the column specs of the result set.
+func (r *Rows) Columns() []*pb.ColumnSpec {
+	return r.resultSet.GetColumns()
+}
+
+// Row returns the current row, or nil if the cursor is not positioned on one.
+func (r *Rows) Row() *pb.Row {
+	if r.pos < 0 || r.pos >= len(r.resultSet.GetRows()) {
+		return nil
+	}
+	return r.resultSet.GetRows()[r.pos]
+}
+
+// Scan copies the columns of the current row into the values pointed at by
+// dest, in column order. Destinations are converted using the column type, see
+// ToGo for the supported conversions.
+func (r *Rows) Scan(dest ...interface{}) error {
+	row := r.Row()
+	if row == nil {
+		return errors.New("scan called without calling next")
+	}
+	if len(dest) != len(row.GetValues()) {
+		return fmt.Errorf("expected %d destination arguments in scan, not %d", len(row.GetValues()), len(dest))
+	}
+
+	for i, value := range row.GetValues() {
+		if err := ToGo(value, r.columnType(i), dest[i]); err != nil {
+			return fmt.Errorf("error scanning column %s: %w", r.columnName(i), err)
+		}
+	}
+
+	return nil
+}
+
+// ScanMap stores each column of the current row in m, keyed by column name.
+// Values are decoded as they would be by the ToXxx functions and null values
+// are stored as nil.
+func (r *Rows) ScanMap(m map[string]interface{}) error {
+	row := r.Row()
+	if row == nil {
+		return errors.New("scan called without calling next")
+	}
+
+	for i, value := range row.GetValues() {
+		v, err := decodeAny(value, r.columnType(i))
+		if err != nil {
+			return fmt.Errorf("error scanning column %s: %w", r.columnName(i), err)
+		}
+		m[r.columnName(i)] = v
+	}
+
+	return nil
+}
+
+func (r *Rows) columnType(i int) *pb.TypeSpec {
+	if i < len(r.resultSet.GetColumns()) {
+		return r.resultSet.GetColumns()[i].GetType()
+	}
+	return nil
+}
+
+func (r *Rows) columnName(i int) string {
+	if i < len(r.resultSet.GetColumns()) {
+		return r.resultSet.GetColumns()[i].GetName()
+	}
+	return fmt.Sprintf("%d", i)
+}
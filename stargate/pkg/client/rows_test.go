@@ -0,0 +1,134 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResultSet() *pb.ResultSet {
+	id := uuid.MustParse("f066f76d-5e96-4b52-8d8a-0f51387df76b")
+	listSpec := &pb.TypeSpec{Spec: &pb.TypeSpec_List_{List: &pb.TypeSpec_List{Element: basicSpec(pb.TypeSpec_TEXT)}}}
+
+	return &pb.ResultSet{
+		Columns: []*pb.ColumnSpec{
+			{Name: "id", Type: basicSpec(pb.TypeSpec_UUID)},
+			{Name: "name", Type: basicSpec(pb.TypeSpec_TEXT)},
+			{Name: "age", Type: basicSpec(pb.TypeSpec_INT)},
+			{Name: "created", Type: basicSpec(pb.TypeSpec_TIMESTAMP)},
+			{Name: "tags", Type: listSpec},
+			{Name: "balance", Type: basicSpec(pb.TypeSpec_VARINT)},
+		},
+		Rows: []*pb.Row{
+			{Values: []*pb.Value{
+				FromUUID(id),
+				FromString("alpha"),
+				FromInt(42),
+				FromInt(1631032831123),
+				FromCollection(FromString("a"), FromString("b")),
+				FromVarInt(big.NewInt(-5)),
+			}},
+			{Values: []*pb.Value{
+				FromUUID(id),
+				NullValue(),
+				FromInt(7),
+				FromInt(0),
+				NullValue(),
+				NullValue(),
+			}},
+		},
+	}
+}
+
+func TestRows_Scan(t *testing.T) {
+	rows := NewRows(testResultSet())
+
+	var (
+		id      uuid.UUID
+		name    *string
+		age     int32
+		created time.Time
+		tags    []string
+		balance *big.Int
+	)
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&id, &name, &age, &created, &tags, &balance))
+	assert.Equal(t, uuid.MustParse("f066f76d-5e96-4b52-8d8a-0f51387df76b"), id)
+	require.NotNil(t, name)
+	assert.Equal(t, "alpha", *name)
+	assert.Equal(t, int32(42), age)
+	assert.Equal(t, time.Date(2021, 9, 7, 16, 40, 31, 123000000, time.UTC), created)
+	assert.Equal(t, []string{"a", "b"}, tags)
+	assert.Equal(t, big.NewInt(-5), balance)
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&id, &name, &age, &created, &tags, &balance))
+	assert.Nil(t, name)
+	assert.Nil(t, tags)
+	assert.Nil(t, balance)
+
+	assert.False(t, rows.Next())
+	assert.False(t, rows.Next())
+}
+
+func TestRows_ScanErrors(t *testing.T) {
+	rows := NewRows(testResultSet())
+
+	var name string
+	assert.EqualError(t, rows.Scan(&name), "scan called without calling next")
+
+	require.True(t, rows.Next())
+	assert.EqualError(t, rows.Scan(&name), "expected 6 destination arguments in scan, not 1")
+
+	var id, age, created, tags, balance string
+	var small int8
+	err := rows.Scan(&id, &name, &small, &created, &tags, &balance)
+	assert.EqualError(t, err, "error scanning column created: cannot decode int into string")
+
+	err = rows.Scan(&id, &name, &age, &created, &tags, &balance)
+	assert.EqualError(t, err, "error scanning column age: cannot decode int into string")
+}
+
+func TestRows_ScanMap(t *testing.T) {
+	rows := NewRows(testResultSet())
+	require.True(t, rows.Next())
+	require.True(t, rows.Next())
+
+	m := map[string]interface{}{}
+	require.NoError(t, rows.ScanMap(m))
+	assert.Equal(t, "f066f76d-5e96-4b52-8d8a-0f51387df76b", m["id"].(*uuid.UUID).String())
+	assert.Nil(t, m["name"])
+	assert.Equal(t, int64(7), m["age"])
+	assert.Nil(t, m["tags"])
+}
+
+func TestRows_ScanMap_NullUdtField(t *testing.T) {
+	udt := &pb.TypeSpec{Spec: &pb.TypeSpec_Udt_{Udt: &pb.TypeSpec_Udt{Fields: map[string]*pb.TypeSpec{
+		"street": basicSpec(pb.TypeSpec_TEXT),
+		"zip":    basicSpec(pb.TypeSpec_INT),
+	}}}}
+	rows := NewRows(&pb.ResultSet{
+		Columns: []*pb.ColumnSpec{{Name: "address", Type: udt}},
+		Rows: []*pb.Row{{Values: []*pb.Value{{Inner: &pb.Value_Udt{Udt: &pb.UdtValue{Fields: map[string]*pb.Value{
+			"street": FromString("Main St"),
+			"zip":    NullValue(),
+		}}}}}}},
+	})
+	require.True(t, rows.Next())
+
+	m := map[string]interface{}{}
+	require.NoError(t, rows.ScanMap(m))
+	assert.Equal(t, map[string]interface{}{"street": "Main St", "zip": nil}, m["address"])
+}
+
+func TestRows_NilResultSet(t *testing.T) {
+	rows := NewRows(nil)
+	assert.False(t, rows.Next())
+	assert.Empty(t, rows.Columns())
+}
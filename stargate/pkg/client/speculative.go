@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// SpeculativeExecutionPolicy decides when an idempotent request that has not
+// yet completed should be sent again, racing the executions already running.
+type SpeculativeExecutionPolicy interface {
+	// Delay returns how long to wait for the started executions before
+	// starting another, or false if no more should be started.
+	Delay(started int) (time.Duration, bool)
+	// Observe records the latency of a successful execution.
+	Observe(latency time.Duration)
+}
+
+// SpeculativeExecutionStats counts how often speculative execution was used.
+type SpeculativeExecutionStats struct {
+	// Requests is the number of requests eligible for speculative execution.
+	Requests uint64
+	// Executions is the number of speculative executions started.
+	Executions uint64
+	// Won is the number of requests answered by a speculative execution
+	// rather than the original one.
+	Won uint64
+}
+
+type speculativeStats struct {
+	requests   uint64
+	executions uint64
+	won        uint64
+}
+
+type constantSpeculativeExecutionPolicy struct {
+	delay         time.Duration
+	maxExecutions int
+}
+
+// NewConstantSpeculativeExecutionPolicy creates a SpeculativeExecutionPolicy
+// that starts a new execution every delay, up to maxExecutions in total
+// including the original.
+func NewConstantSpeculativeExecutionPolicy(delay time.Duration, maxExecutions int) SpeculativeExecutionPolicy {
+	return constantSpeculativeExecutionPolicy{
+		delay:         delay,
+		maxExecutions: maxExecutions,
+	}
+}
+
+func (p constantSpeculativeExecutionPolicy) Delay(started int) (time.Duration, bool) {
+	return p.delay, started < p.maxExecutions
+}
+
+func (constantSpeculativeExecutionPolicy) Observe(time.Duration) {}
+
+// percentileWindow is the number of recent latencies used to compute the
+// percentile, and percentileRefresh how many observations are made between
+// recomputing it.
+const (
+	percentileWindow  = 1024
+	percentileRefresh = 64
+)
+
+type percentileSpeculativeExecutionPolicy struct {
+	percentile    float64
+	maxExecutions int
+	minSamples    int
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	pending   int
+	delay     time.Duration
+}
+
+// NewPercentileSpeculativeExecutionPolicy creates a SpeculativeExecutionPolicy
+// that starts a new execution once a request has taken longer than the given
+// percentile, between 0 and 100, of recently observed latencies. At most
+// maxExecutions are started in total including the original, and nothing is
+// speculated until minSamples latencies have been observed.
+func NewPercentileSpeculativeExecutionPolicy(percentile float64, maxExecutions, minSamples int) SpeculativeExecutionPolicy {
+	return &percentileSpeculativeExecutionPolicy{
+		percentile:    percentile,
+		maxExecutions: maxExecutions,
+		minSamples:    minSamples,
+		latencies:     make([]time.Duration, 0, percentileWindow),
+	}
+}
+
+func (p *percentileSpeculativeExecutionPolicy) Delay(started int) (time.Duration, bool) {
+	if started >= p.maxExecutions {
+		return 0, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.latencies) < p.minSamples || len(p.latencies) == 0 {
+		return 0, false
+	}
+	if p.delay == 0 {
+		p.recompute()
+	}
+	return p.delay, true
+}
+
+func (p *percentileSpeculativeExecutionPolicy) Observe(latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.latencies) < percentileWindow {
+		p.latencies = append(p.latencies, latency)
+	} else {
+		p.latencies[p.next] = latency
+		p.next = (p.next + 1) % percentileWindow
+	}
+
+	p.pending++
+	if p.pending >= percentileRefresh {
+		p.recompute()
+	}
+}
+
+func (p *percentileSpeculativeExecutionPolicy) recompute() {
+	sorted := make([]time.Duration, len(p.latencies))
+	copy(sorted, p.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	i := int(math.Ceil(p.percentile/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	p.delay = sorted[i]
+	p.pending = 0
+}
+
+// WithSpeculativeExecution returns a StargateClientOption which enables
+// speculative execution of idempotent queries and batches using policy. The
+// first successful response is returned and the other executions are
+// cancelled.
+func WithSpeculativeExecution(policy SpeculativeExecutionPolicy) StargateClientOption {
+	return func(c *StargateClient) {
+		c.speculativePolicy = policy
+	}
+}
+
+// SpeculativeExecutionStats returns how often speculative execution has been
+// used by the client.
+func (s *StargateClient) SpeculativeExecutionStats() SpeculativeExecutionStats {
+	return SpeculativeExecutionStats{
+		Requests:   atomic.LoadUint64(&s.speculativeStats.requests),
+		Executions: atomic.LoadUint64(&s.speculativeStats.executions),
+		Won:        atomic.LoadUint64(&s.speculativeStats.won),
+	}
+}
+
+type execution struct {
+	resp    *pb.Response
+	err     error
+	n       int
+	latency time.Duration
+}
+
+// speculate runs call, starting further executions as directed by the
+// client's speculative execution policy if the request is idempotent.
+func (s *StargateClient) speculate(
+	ctx context.Context,
+	kind RequestKind,
+	idempotent bool,
+	call func(ctx context.Context) (*pb.Response, error),
+) (*pb.Response, error) {
+	policy := s.speculativePolicy
+	if policy == nil {
+		return call(ctx)
+	}
+	if !idempotent {
+		start := time.Now()
+		resp, err := call(ctx)
+		if err == nil {
+			policy.Observe(time.Since(start))
+		}
+		return resp, err
+	}
+
+	atomic.AddUint64(&s.speculativeStats.requests, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan execution)
+	launch := func(n int) {
+		go func() {
+			start := time.Now()
+			resp, err := call(ctx)
+			select {
+			case results <- execution{resp: resp, err: err, n: n, latency: time.Since(start)}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	launch(1)
+	started, running := 1, 1
+	lastLaunch := time.Now()
+	var lastErr error
+	for {
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if delay, ok := policy.Delay(started); ok {
+			// The delay runs from the start of the last execution, not from
+			// whenever an earlier one happened to fail.
+			timer = time.NewTimer(delay - time.Since(lastLaunch))
+			timeout = timer.C
+		}
+
+		select {
+		case result := <-results:
+			if timer != nil {
+				timer.Stop()
+			}
+			running--
+			if result.err == nil {
+				policy.Observe(result.latency)
+				if result.n > 1 {
+					atomic.AddUint64(&s.speculativeStats.won, 1)
+				}
+				return result.resp, nil
+			}
+			lastErr = result.err
+			if running == 0 {
+				return nil, lastErr
+			}
+		case <-timeout:
+			started++
+			running++
+			atomic.AddUint64(&s.speculativeStats.executions, 1)
+			s.metrics.SpeculativeExecution(kind)
+			launch(started)
+			lastLaunch = time.Now()
+		case <-ctx.Done():
+			// Executions drop their results once the context is done, so
+			// there is nothing left to wait for.
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
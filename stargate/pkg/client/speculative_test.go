@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowFirstStargate blocks the first query until its context is cancelled and
+// answers every later one immediately.
+func slowFirstStargate(calls *int32) *fakeStargate {
+	return &fakeStargate{
+		executeQuery: func(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+			if atomic.AddInt32(calls, 1) == 1 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &pb.Response{}, nil
+		},
+	}
+}
+
+func TestSpeculativeExecution(t *testing.T) {
+	var calls int32
+	s := newFakeClient(slowFirstStargate(&calls),
+		WithSpeculativeExecution(NewConstantSpeculativeExecutionPolicy(10*time.Millisecond, 2)))
+
+	resp, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM ks.tbl"})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, SpeculativeExecutionStats{Requests: 1, Executions: 1, Won: 1}, s.SpeculativeExecutionStats())
+}
+
+func TestSpeculativeExecution_NotIdempotent(t *testing.T) {
+	var calls int32
+	s := newFakeClient(slowFirstStargate(&calls),
+		WithSpeculativeExecution(NewConstantSpeculativeExecutionPolicy(time.Millisecond, 3)),
+		WithTimeout(50*time.Millisecond))
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "UPDATE ks.tbl SET c = c + 1 WHERE k = 1"})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, SpeculativeExecutionStats{}, s.SpeculativeExecutionStats())
+}
+
+func TestSpeculativeExecution_AllFail(t *testing.T) {
+	var calls int32
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(5 * time.Millisecond)
+			return nil, context.DeadlineExceeded
+		},
+	}, WithSpeculativeExecution(NewConstantSpeculativeExecutionPolicy(time.Millisecond, 3)))
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM ks.tbl"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestSpeculativeExecution_CallerTimeout(t *testing.T) {
+	var calls int32
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(100 * time.Millisecond)
+			return &pb.Response{}, nil
+		},
+	}, WithSpeculativeExecution(NewConstantSpeculativeExecutionPolicy(time.Millisecond, 2)),
+		WithTimeout(10*time.Millisecond))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM ks.tbl"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("speculative execution did not return after the caller's deadline")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestSpeculativeExecution_DelayFromLastLaunch(t *testing.T) {
+	var calls int32
+	start := time.Now()
+	var launches [3]time.Duration
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(ctx context.Context, query *pb.Query) (*pb.Response, error) {
+			n := atomic.AddInt32(&calls, 1)
+			launches[n-1] = time.Since(start)
+			switch n {
+			case 1:
+				<-ctx.Done()
+				return nil, ctx.Err()
+			case 2:
+				// Fail just before the next execution is due.
+				time.Sleep(40 * time.Millisecond)
+				return nil, errors.New("failed")
+			}
+			return &pb.Response{}, nil
+		},
+	}, WithSpeculativeExecution(NewConstantSpeculativeExecutionPolicy(50*time.Millisecond, 3)))
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM ks.tbl"})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	// The third execution is due 50ms after the second started, not 50ms
+	// after it failed.
+	assert.Less(t, int64(launches[2]-launches[1]), int64(80*time.Millisecond))
+}
+
+func TestPercentileSpeculativeExecutionPolicy(t *testing.T) {
+	p := NewPercentileSpeculativeExecutionPolicy(90, 2, 10)
+
+	for i := 1; i < 10; i++ {
+		p.Observe(time.Duration(i) * time.Millisecond)
+	}
+	_, ok := p.Delay(1)
+	assert.False(t, ok, "no delay before min samples")
+
+	p.Observe(10 * time.Millisecond)
+	delay, ok := p.Delay(1)
+	assert.True(t, ok)
+	assert.Equal(t, 9*time.Millisecond, delay)
+
+	_, ok = p.Delay(2)
+	assert.False(t, ok, "no delay once max executions started")
+}
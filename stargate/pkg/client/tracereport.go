@@ -0,0 +1,239 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// activityNumber matches the numbers, IP addresses and ports in trace
+// activities, which are replaced so that similar activities are grouped.
+var activityNumber = regexp.MustCompile(`\b\d+(?:[.:]\d+)*\b`)
+
+// WithTracing returns a CallOption which enables server-side tracing of the
+// statement. The trace is returned in the response and can be summarized with
+// NewTraceReport.
+func WithTracing() CallOption {
+	return func(o *callOptions) {
+		o.tracing = true
+	}
+}
+
+// applyToQuery returns query with tracing enabled if requested, cloning it
+// rather than modifying the caller's query.
+func (o *callOptions) applyToQuery(query *pb.Query) *pb.Query {
+	if !o.tracing || query.GetParameters().GetTracing() {
+		return query
+	}
+
+	query = proto.Clone(query).(*pb.Query)
+	if query.Parameters == nil {
+		query.Parameters = &pb.QueryParameters{}
+	}
+	query.Parameters.Tracing = true
+	return query
+}
+
+// applyToBatch returns batch with tracing enabled if requested, cloning it
+// rather than modifying the caller's batch.
+func (o *callOptions) applyToBatch(batch *pb.Batch) *pb.Batch {
+	if !o.tracing || batch.GetParameters().GetTracing() {
+		return batch
+	}
+
+	batch = proto.Clone(batch).(*pb.Batch)
+	if batch.Parameters == nil {
+		batch.Parameters = &pb.BatchParameters{}
+	}
+	batch.Parameters.Tracing = true
+	return batch
+}
+
+// TraceReport summarizes a server-side trace.
+type TraceReport struct {
+	ID        string
+	StartedAt time.Time
+	// Duration is the time the coordinator took to handle the request.
+	Duration time.Duration
+	// Events are the events of the trace, in the order they were returned.
+	Events []TraceEvent
+	// Sources are the hosts that recorded events, in order of their first
+	// event.
+	Sources []SourceTiming
+	// Activities group events whose activities only differ by numbers, such
+	// as row counts or addresses, slowest first.
+	Activities []ActivityTiming
+}
+
+// TraceEvent is an event of a trace.
+type TraceEvent struct {
+	Activity string
+	Source   string
+	Thread   string
+	// Elapsed is the time since the source started handling the request.
+	Elapsed time.Duration
+	// Delta is the time since the previous event of the same source, which
+	// is attributed to this event's activity.
+	Delta time.Duration
+}
+
+// SourceTiming is the time spent by one host.
+type SourceTiming struct {
+	Source string
+	Events int
+	// Elapsed is the time of the last event of the source.
+	Elapsed time.Duration
+}
+
+// ActivityTiming is the time attributed to an activity across every host.
+type ActivityTiming struct {
+	Activity string
+	Count    int
+	Total    time.Duration
+}
+
+// NewTraceReport creates a TraceReport from the trace of a response to a
+// query or batch executed with WithTracing. It returns nil if traces is nil.
+func NewTraceReport(traces *pb.Traces) *TraceReport {
+	if traces == nil {
+		return nil
+	}
+
+	report := &TraceReport{
+		ID:        traces.GetId(),
+		StartedAt: time.UnixMilli(traces.GetStartedAt()),
+		Duration:  time.Duration(traces.GetDuration()) * time.Microsecond,
+		Events:    make([]TraceEvent, len(traces.GetEvents())),
+	}
+
+	sources := map[string]int{}
+	activities := map[string]int{}
+	for i, e := range traces.GetEvents() {
+		event := TraceEvent{
+			Activity: e.GetActivity(),
+			Source:   e.GetSource(),
+			Thread:   e.GetThread(),
+			Elapsed:  time.Duration(e.GetSourceElapsed()) * time.Microsecond,
+		}
+
+		j, ok := sources[event.Source]
+		if !ok {
+			j = len(report.Sources)
+			sources[event.Source] = j
+			report.Sources = append(report.Sources, SourceTiming{Source: event.Source})
+		}
+		source := &report.Sources[j]
+		if event.Elapsed > source.Elapsed {
+			event.Delta = event.Elapsed - source.Elapsed
+			source.Elapsed = event.Elapsed
+		}
+		source.Events++
+
+		name := activityNumber.ReplaceAllString(event.Activity, "?")
+		k, ok := activities[name]
+		if !ok {
+			k = len(report.Activities)
+			activities[name] = k
+			report.Activities = append(report.Activities, ActivityTiming{Activity: name})
+		}
+		report.Activities[k].Count++
+		report.Activities[k].Total += event.Delta
+
+		report.Events[i] = event
+	}
+
+	sort.SliceStable(report.Activities, func(i, j int) bool {
+		return report.Activities[i].Total > report.Activities[j].Total
+	})
+	return report
+}
+
+// WriteText writes the report as tables of events, sources and activities.
+func (r *TraceReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Trace %s started at %s, took %s\n\n", r.ID, r.StartedAt.UTC().Format(time.RFC3339Nano), r.Duration)
+	fmt.Fprintln(tw, "ELAPSED\tDELTA\tSOURCE\tTHREAD\tACTIVITY")
+	for _, e := range r.Events {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Elapsed, e.Delta, e.Source, e.Thread, e.Activity)
+	}
+
+	fmt.Fprintln(tw, "\nSOURCE\tEVENTS\tELAPSED")
+	for _, s := range r.Sources {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", s.Source, s.Events, s.Elapsed)
+	}
+
+	fmt.Fprintln(tw, "\nACTIVITY\tCOUNT\tTOTAL")
+	for _, a := range r.Activities {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", a.Activity, a.Count, a.Total)
+	}
+
+	return tw.Flush()
+}
+
+// String returns the report as text, as written by WriteText.
+func (r *TraceReport) String() string {
+	var sb strings.Builder
+	_ = r.WriteText(&sb)
+	return sb.String()
+}
+
+type traceReportJSON struct {
+	ID             string               `json:"id"`
+	StartedAt      time.Time            `json:"started_at"`
+	DurationMicros int64                `json:"duration_micros"`
+	Events         []traceEventJSON     `json:"events"`
+	Sources        []sourceTimingJSON   `json:"sources"`
+	Activities     []activityTimingJSON `json:"activities"`
+}
+
+type traceEventJSON struct {
+	Activity      string `json:"activity"`
+	Source        string `json:"source"`
+	Thread        string `json:"thread"`
+	ElapsedMicros int64  `json:"elapsed_micros"`
+	DeltaMicros   int64  `json:"delta_micros"`
+}
+
+type sourceTimingJSON struct {
+	Source        string `json:"source"`
+	Events        int    `json:"events"`
+	ElapsedMicros int64  `json:"elapsed_micros"`
+}
+
+type activityTimingJSON struct {
+	Activity    string `json:"activity"`
+	Count       int    `json:"count"`
+	TotalMicros int64  `json:"total_micros"`
+}
+
+// MarshalJSON encodes the report with snake_case keys and durations in
+// microseconds, the unit Cassandra records them in.
+func (r *TraceReport) MarshalJSON() ([]byte, error) {
+	out := traceReportJSON{
+		ID:             r.ID,
+		StartedAt:      r.StartedAt.UTC(),
+		DurationMicros: r.Duration.Microseconds(),
+		Events:         make([]traceEventJSON, len(r.Events)),
+		Sources:        make([]sourceTimingJSON, len(r.Sources)),
+		Activities:     make([]activityTimingJSON, len(r.Activities)),
+	}
+	for i, e := range r.Events {
+		out.Events[i] = traceEventJSON{e.Activity, e.Source, e.Thread, e.Elapsed.Microseconds(), e.Delta.Microseconds()}
+	}
+	for i, s := range r.Sources {
+		out.Sources[i] = sourceTimingJSON{s.Source, s.Events, s.Elapsed.Microseconds()}
+	}
+	for i, a := range r.Activities {
+		out.Activities[i] = activityTimingJSON{a.Activity, a.Count, a.Total.Microseconds()}
+	}
+	return json.Marshal(out)
+}
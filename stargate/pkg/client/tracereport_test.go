@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTraces = &pb.Traces{
+	Id:        "5d1a2c30-c6b0-11eb-9f7d-5f3e7b2f0a11",
+	Duration:  1500,
+	StartedAt: 1622800000000,
+	Events: []*pb.Traces_Event{
+		{Activity: "Parsing SELECT * FROM ks.events WHERE day = ?", Source: "10.0.0.1", SourceElapsed: 100, Thread: "Native-Transport-Requests-1"},
+		{Activity: "Sending READ message to /10.0.0.2:7000", Source: "10.0.0.1", SourceElapsed: 300, Thread: "Native-Transport-Requests-1"},
+		{Activity: "Read 10 live rows and 2000 tombstone cells", Source: "10.0.0.2", SourceElapsed: 900, Thread: "ReadStage-2"},
+		{Activity: "Read 12 live rows and 1500 tombstone cells", Source: "10.0.0.2", SourceElapsed: 1200, Thread: "ReadStage-2"},
+		{Activity: "Processing response from /10.0.0.2:7000", Source: "10.0.0.1", SourceElapsed: 1400, Thread: "RequestResponseStage-3"},
+	},
+}
+
+func TestNewTraceReport(t *testing.T) {
+	assert.Nil(t, NewTraceReport(nil))
+
+	report := NewTraceReport(testTraces)
+	assert.Equal(t, "5d1a2c30-c6b0-11eb-9f7d-5f3e7b2f0a11", report.ID)
+	assert.True(t, report.StartedAt.Equal(time.Date(2021, 6, 4, 9, 46, 40, 0, time.UTC)))
+	assert.Equal(t, 1500*time.Microsecond, report.Duration)
+
+	require.Len(t, report.Events, 5)
+	assert.Equal(t, TraceEvent{
+		Activity: "Read 10 live rows and 2000 tombstone cells",
+		Source:   "10.0.0.2",
+		Thread:   "ReadStage-2",
+		Elapsed:  900 * time.Microsecond,
+		Delta:    900 * time.Microsecond,
+	}, report.Events[2])
+	assert.Equal(t, 1100*time.Microsecond, report.Events[4].Delta)
+
+	assert.Equal(t, []SourceTiming{
+		{Source: "10.0.0.1", Events: 3, Elapsed: 1400 * time.Microsecond},
+		{Source: "10.0.0.2", Events: 2, Elapsed: 1200 * time.Microsecond},
+	}, report.Sources)
+	assert.Equal(t, []ActivityTiming{
+		{Activity: "Read ? live rows and ? tombstone cells", Count: 2, Total: 1200 * time.Microsecond},
+		{Activity: "Processing response from /?", Count: 1, Total: 1100 * time.Microsecond},
+		{Activity: "Sending READ message to /?", Count: 1, Total: 200 * time.Microsecond},
+		{Activity: "Parsing SELECT * FROM ks.events WHERE day = ?", Count: 1, Total: 100 * time.Microsecond},
+	}, report.Activities)
+}
+
+func TestTraceReport_Text(t *testing.T) {
+	expected := `Trace 5d1a2c30-c6b0-11eb-9f7d-5f3e7b2f0a11 started at 2021-06-04T09:46:40Z, took 1.5ms
+
+ELAPSED  DELTA  SOURCE    THREAD                       ACTIVITY
+100µs    100µs  10.0.0.1  Native-Transport-Requests-1  Parsing SELECT * FROM ks.events WHERE day = ?
+300µs    200µs  10.0.0.1  Native-Transport-Requests-1  Sending READ message to /10.0.0.2:7000
+900µs    900µs  10.0.0.2  ReadStage-2                  Read 10 live rows and 2000 tombstone cells
+1.2ms    300µs  10.0.0.2  ReadStage-2                  Read 12 live rows and 1500 tombstone cells
+1.4ms    1.1ms  10.0.0.1  RequestResponseStage-3       Processing response from /10.0.0.2:7000
+
+SOURCE    EVENTS  ELAPSED
+10.0.0.1  3       1.4ms
+10.0.0.2  2       1.2ms
+
+ACTIVITY                                       COUNT  TOTAL
+Read ? live rows and ? tombstone cells         2      1.2ms
+Processing response from /?                    1      1.1ms
+Sending READ message to /?                     1      200µs
+Parsing SELECT * FROM ks.events WHERE day = ?  1      100µs
+`
+	assert.Equal(t, expected, NewTraceReport(testTraces).String())
+}
+
+func TestTraceReport_JSON(t *testing.T) {
+	data, err := json.Marshal(NewTraceReport(&pb.Traces{
+		Id:        "5d1a2c30-c6b0-11eb-9f7d-5f3e7b2f0a11",
+		Duration:  500,
+		StartedAt: 1622800000000,
+		Events: []*pb.Traces_Event{
+			{Activity: "Parsing SELECT * FROM t", Source: "10.0.0.1", SourceElapsed: 120, Thread: "Native-Transport-Requests-1"},
+		},
+	}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "5d1a2c30-c6b0-11eb-9f7d-5f3e7b2f0a11",
+		"started_at": "2021-06-04T09:46:40Z",
+		"duration_micros": 500,
+		"events": [{"activity": "Parsing SELECT * FROM t", "source": "10.0.0.1", "thread": "Native-Transport-Requests-1", "elapsed_micros": 120, "delta_micros": 120}],
+		"sources": [{"source": "10.0.0.1", "events": 1, "elapsed_micros": 120}],
+		"activities": [{"activity": "Parsing SELECT * FROM t", "count": 1, "total_micros": 120}]
+	}`, string(data))
+}
+
+func TestWithTracing(t *testing.T) {
+	var tracing []bool
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(_ context.Context, query *pb.Query) (*pb.Response, error) {
+			tracing = append(tracing, query.GetParameters().GetTracing())
+			return &pb.Response{Traces: testTraces}, nil
+		},
+		executeBatch: func(_ context.Context, batch *pb.Batch) (*pb.Response, error) {
+			tracing = append(tracing, batch.GetParameters().GetTracing())
+			return &pb.Response{}, nil
+		},
+	})
+
+	query := &pb.Query{Cql: "SELECT * FROM t"}
+	resp, err := s.ExecuteQueryWithOptions(query, context.Background(), WithTracing())
+	require.NoError(t, err)
+	assert.Equal(t, "5d1a2c30-c6b0-11eb-9f7d-5f3e7b2f0a11", NewTraceReport(resp.GetTraces()).ID)
+	assert.Nil(t, query.Parameters, "the caller's query must not be modified")
+
+	_, err = s.ExecuteQuery(query)
+	require.NoError(t, err)
+	_, err = s.ExecuteBatchWithOptions(&pb.Batch{Queries: []*pb.BatchQuery{{Cql: "INSERT INTO t (k) VALUES (1)"}}}, context.Background(), WithTracing())
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{true, false, true}, tracing)
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+
+// Attributes not covered by the semantic conventions for Cassandra.
+var (
+	rowCountKey      = attribute.Key("db.response.returned_rows")
+	errorTypeKey     = attribute.Key("error.type")
+	batchSizeKey     = attribute.Key("db.cassandra.batch_size")
+	serverTraceIDKey = attribute.Key("db.cassandra.trace_id")
+)
+
+var (
+	dollarLiteral  = regexp.MustCompile(`\$\$(?s:.*?)\$\$`)
+	uuidLiteral    = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	blobLiteral    = regexp.MustCompile(`\b0[xX][0-9a-fA-F]*\b`)
+	numericLiteral = regexp.MustCompile(`(^|[^\w.])-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?\b`)
+	whitespace     = regexp.MustCompile(`\s+`)
+	firstKeyword   = regexp.MustCompile(`^\s*([A-Za-z]+)`)
+)
+
+// WithTracerProvider returns a StargateClientOption which creates an
+// OpenTelemetry span for every query and batch, covering any retries and
+// speculative executions. When a query or batch is executed with tracing
+// enabled, the events of the server-side trace are added to the span.
+func WithTracerProvider(provider trace.TracerProvider) StargateClientOption {
+	return func(c *StargateClient) {
+		c.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// spanEnder ends a span with the outcome of the request it covers.
+type spanEnder func(resp *pb.Response, err error)
+
+func (s *StargateClient) startQuerySpan(ctx context.Context, query *pb.Query) (context.Context, spanEnder) {
+	if s.tracer == nil {
+		return ctx, func(*pb.Response, error) {}
+	}
+
+	params := query.GetParameters()
+	operation := cqlOperation(query.GetCql())
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemCassandra,
+		semconv.DBStatementKey.String(sanitizeCQL(query.GetCql())),
+		semconv.DBOperationKey.String(operation),
+	}
+	attrs = appendParamAttributes(attrs, params.GetKeyspace().GetValue(), params.GetConsistency())
+	if params.GetPageSize() != nil {
+		attrs = append(attrs, semconv.DBCassandraPageSizeKey.Int64(int64(params.GetPageSize().GetValue())))
+	}
+
+	return s.startSpan(ctx, spanName(operation, params.GetKeyspace().GetValue()), attrs)
+}
+
+func (s *StargateClient) startBatchSpan(ctx context.Context, batch *pb.Batch) (context.Context, spanEnder) {
+	if s.tracer == nil {
+		return ctx, func(*pb.Response, error) {}
+	}
+
+	statements := make([]string, len(batch.GetQueries()))
+	for i, query := range batch.GetQueries() {
+		statements[i] = sanitizeCQL(query.GetCql())
+	}
+
+	params := batch.GetParameters()
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemCassandra,
+		semconv.DBStatementKey.String(strings.Join(statements, "; ")),
+		semconv.DBOperationKey.String("BATCH"),
+		batchSizeKey.Int(len(statements)),
+	}
+	attrs = appendParamAttributes(attrs, params.GetKeyspace().GetValue(), params.GetConsistency())
+
+	return s.startSpan(ctx, spanName("BATCH", params.GetKeyspace().GetValue()), attrs)
+}
+
+func appendParamAttributes(attrs []attribute.KeyValue, keyspace string, consistency *pb.ConsistencyValue) []attribute.KeyValue {
+	if keyspace != "" {
+		attrs = append(attrs, semconv.DBNameKey.String(keyspace))
+	}
+	if consistency != nil {
+		attrs = append(attrs, semconv.DBCassandraConsistencyLevelKey.String(strings.ToLower(consistency.GetValue().String())))
+	}
+	return attrs
+}
+
+func (s *StargateClient) startSpan(ctx context.Context, name string, attrs []attribute.KeyValue) (context.Context, spanEnder) {
+	ctx, span := s.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	return ctx, func(resp *pb.Response, err error) {
+		defer span.End()
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+			span.SetAttributes(errorTypeKey.String(errorClass(err)))
+			return
+		}
+		if rs := resp.GetResultSet(); rs != nil {
+			span.SetAttributes(rowCountKey.Int(len(rs.GetRows())))
+		}
+		addServerTrace(span, resp.GetTraces())
+	}
+}
+
+// addServerTrace adds the events of a server-side trace to span. Events are
+// timestamped by adding their elapsed time on the replica that recorded them
+// to the start of the trace, so the timestamps of different replicas are only
+// approximately aligned.
+func addServerTrace(span trace.Span, traces *pb.Traces) {
+	if traces == nil {
+		return
+	}
+
+	span.SetAttributes(serverTraceIDKey.String(traces.GetId()))
+	startedAt := time.UnixMilli(traces.GetStartedAt())
+	for _, event := range traces.GetEvents() {
+		elapsed := time.Duration(event.GetSourceElapsed()) * time.Microsecond
+		span.AddEvent(event.GetActivity(),
+			trace.WithTimestamp(startedAt.Add(elapsed)),
+			trace.WithAttributes(
+				attribute.String("source", event.GetSource()),
+				attribute.String("thread", event.GetThread()),
+				attribute.Int64("source_elapsed_micros", event.GetSourceElapsed()),
+			),
+		)
+	}
+}
+
+// sanitizeCQL replaces the literals of a CQL statement with ? so that it can
+// be recorded without leaking the values it contains.
+func sanitizeCQL(cql string) string {
+	cql = stringLiteral.ReplaceAllString(cql, "?")
+	cql = dollarLiteral.ReplaceAllString(cql, "?")
+	cql = uuidLiteral.ReplaceAllString(cql, "?")
+	cql = blobLiteral.ReplaceAllString(cql, "?")
+	cql = numericLiteral.ReplaceAllString(cql, "${1}?")
+	return strings.TrimSpace(whitespace.ReplaceAllString(cql, " "))
+}
+
+func cqlOperation(cql string) string {
+	m := firstKeyword.FindStringSubmatch(cql)
+	if m == nil {
+		return "QUERY"
+	}
+	return strings.ToUpper(m[1])
+}
+
+func spanName(operation, keyspace string) string {
+	if keyspace == "" {
+		return operation
+	}
+	return operation + " " + keyspace
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// recordingTracer is a trace.TracerProvider and trace.Tracer keeping the
+// spans it starts.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	trace.Span
+	name   string
+	kind   trace.SpanKind
+	attrs  map[attribute.Key]attribute.Value
+	events []recordedEvent
+	errs   []error
+	status otelcodes.Code
+	ended  bool
+}
+
+type recordedEvent struct {
+	name      string
+	timestamp time.Time
+	attrs     []attribute.KeyValue
+}
+
+func (r *recordingTracer) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return r
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(opts...)
+	span := &recordedSpan{
+		Span:  trace.SpanFromContext(ctx),
+		name:  name,
+		kind:  config.SpanKind(),
+		attrs: make(map[attribute.Key]attribute.Value),
+	}
+	span.SetAttributes(config.Attributes()...)
+
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) AddEvent(name string, opts ...trace.EventOption) {
+	config := trace.NewEventConfig(opts...)
+	s.events = append(s.events, recordedEvent{name: name, timestamp: config.Timestamp(), attrs: config.Attributes()})
+}
+
+func (s *recordedSpan) RecordError(err error, _ ...trace.EventOption) { s.errs = append(s.errs, err) }
+func (s *recordedSpan) SetStatus(code otelcodes.Code, _ string)       { s.status = code }
+func (s *recordedSpan) End(...trace.SpanEndOption)                    { s.ended = true }
+
+func TestTracing_Query(t *testing.T) {
+	tracer := &recordingTracer{}
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(context.Context, *pb.Query) (*pb.Response, error) {
+			return &pb.Response{Result: &pb.Response_ResultSet{ResultSet: &pb.ResultSet{
+				Rows: []*pb.Row{{}, {}, {}},
+			}}}, nil
+		},
+	}, WithTracerProvider(tracer))
+
+	_, err := s.ExecuteQuery(&pb.Query{
+		Cql: "SELECT * FROM users\n  WHERE name = 'alice' AND age > 30 AND id = 3f1a7c2e-8d4b-4f6a-9c1e-2b7d5e8f0a13",
+		Parameters: &pb.QueryParameters{
+			Keyspace:    wrapperspb.String("app"),
+			Consistency: &pb.ConsistencyValue{Value: pb.Consistency_LOCAL_QUORUM},
+			PageSize:    wrapperspb.Int32(50),
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, "SELECT app", span.name)
+	assert.Equal(t, trace.SpanKindClient, span.kind)
+	assert.True(t, span.ended)
+	assert.Equal(t, "cassandra", span.attrs["db.system"].AsString())
+	assert.Equal(t, "SELECT * FROM users WHERE name = ? AND age > ? AND id = ?", span.attrs["db.statement"].AsString())
+	assert.Equal(t, "app", span.attrs["db.name"].AsString())
+	assert.Equal(t, "local_quorum", span.attrs["db.cassandra.consistency_level"].AsString())
+	assert.Equal(t, int64(50), span.attrs["db.cassandra.page_size"].AsInt64())
+	assert.Equal(t, int64(3), span.attrs["db.response.returned_rows"].AsInt64())
+	assert.Equal(t, otelcodes.Unset, span.status)
+}
+
+func TestTracing_Error(t *testing.T) {
+	tracer := &recordingTracer{}
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(context.Context, *pb.Query) (*pb.Response, error) {
+			return nil, statusWithDetails(t, codes.Unavailable, &pb.Unavailable{Required: 2, Alive: 1})
+		},
+	}, WithTracerProvider(tracer))
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "INSERT INTO t (k) VALUES (1)"})
+	require.Error(t, err)
+
+	span := tracer.spans[0]
+	assert.Equal(t, "INSERT", span.name)
+	assert.Equal(t, otelcodes.Error, span.status)
+	assert.Equal(t, "UnavailableError", span.attrs["error.type"].AsString())
+	assert.Len(t, span.errs, 1)
+	assert.True(t, span.ended)
+}
+
+func TestTracing_BatchWithServerTrace(t *testing.T) {
+	tracer := &recordingTracer{}
+	startedAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := newFakeClient(&fakeStargate{
+		executeBatch: func(context.Context, *pb.Batch) (*pb.Response, error) {
+			return &pb.Response{Traces: &pb.Traces{
+				Id:        "trace-1",
+				StartedAt: startedAt.UnixMilli(),
+				Events: []*pb.Traces_Event{
+					{Activity: "Parsing statement", Source: "10.0.0.1", SourceElapsed: 150, Thread: "Native-1"},
+					{Activity: "Appending to commitlog", Source: "10.0.0.2", SourceElapsed: 900, Thread: "Mutation-2"},
+				},
+			}}, nil
+		},
+	}, WithTracerProvider(tracer))
+
+	_, err := s.ExecuteBatch(&pb.Batch{
+		Queries: []*pb.BatchQuery{
+			{Cql: "INSERT INTO t (k, v) VALUES (1, 'a')"},
+			{Cql: "UPDATE t SET v = 0xcafe WHERE k = 2"},
+		},
+		Parameters: &pb.BatchParameters{Tracing: true},
+	})
+	require.NoError(t, err)
+
+	span := tracer.spans[0]
+	assert.Equal(t, "BATCH", span.name)
+	assert.Equal(t, "INSERT INTO t (k, v) VALUES (?, ?); UPDATE t SET v = ? WHERE k = ?", span.attrs["db.statement"].AsString())
+	assert.Equal(t, int64(2), span.attrs["db.cassandra.batch_size"].AsInt64())
+	assert.Equal(t, "trace-1", span.attrs["db.cassandra.trace_id"].AsString())
+
+	require.Len(t, span.events, 2)
+	assert.Equal(t, "Parsing statement", span.events[0].name)
+	assert.True(t, startedAt.Add(150*time.Microsecond).Equal(span.events[0].timestamp))
+	assert.Contains(t, span.events[1].attrs, attribute.String("source", "10.0.0.2"))
+}
+
+func TestTracing_Disabled(t *testing.T) {
+	s := newFakeClient(&fakeStargate{
+		executeQuery: func(ctx context.Context, _ *pb.Query) (*pb.Response, error) {
+			assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+			return &pb.Response{}, nil
+		},
+	})
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM t"})
+	require.NoError(t, err)
+}
+
+func TestSanitizeCQL(t *testing.T) {
+	tests := []struct {
+		cql  string
+		want string
+	}{
+		{"SELECT * FROM t WHERE k = ?", "SELECT * FROM t WHERE k = ?"},
+		{"SELECT v1, v_2 FROM t2 WHERE k = 'it''s' LIMIT 10", "SELECT v1, v_2 FROM t2 WHERE k = ? LIMIT ?"},
+		{"INSERT INTO t (k, f) VALUES (-1.5e3, $$body$$)", "INSERT INTO t (k, f) VALUES (?, ?)"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sanitizeCQL(tt.cql), tt.cql)
+	}
+}
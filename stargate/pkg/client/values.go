@@ -1,8 +1,8 @@
 package client
 
 import (
-	"encoding/binary"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/google/uuid"
@@ -75,8 +75,11 @@ func ToBoolean(val *pb.Value) (bool, error) {
 
 func ToDecimal(val *pb.Value) (*inf.Dec, error) {
 	if val, ok := val.GetInner().(*pb.Value_Decimal); ok {
-		value, _ := binary.Uvarint(val.Decimal.Value)
-		return inf.NewDec(int64(value), inf.Scale(val.Decimal.Scale)), nil
+		unscaled, err := decodeVarint(val.Decimal.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("invalid decimal: %w", err)
+		}
+		return inf.NewDecBig(unscaled, inf.Scale(int32(val.Decimal.GetScale()))), nil
 	}
 	return nil, errors.New("not a decimal")
 }
@@ -102,12 +105,15 @@ func ToInet(val *pb.Value) ([]byte, error) {
 	return nil, errors.New("not an inet")
 }
 
-func ToVarInt(val *pb.Value) (uint64, error) {
+func ToVarInt(val *pb.Value) (*big.Int, error) {
 	if val, ok := val.GetInner().(*pb.Value_Varint); ok {
-		value, _ := binary.Uvarint(val.Varint.Value)
+		value, err := decodeVarint(val.Varint.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("invalid varint: %w", err)
+		}
 		return value, nil
 	}
-	return 0, errors.New("not a varint")
+	return nil, errors.New("not a varint")
 }
 
 func ToDate(val *pb.Value) (uint32, error) {
@@ -160,6 +166,10 @@ func ToTuple(val *pb.Value, spec *pb.TypeSpec) (interface{}, error) {
 }
 
 func translateType(value *pb.Value, spec *pb.TypeSpec) (interface{}, error) {
+	// Elements of tuples and fields of UDTs may be null.
+	if isNull(value) {
+		return nil, nil
+	}
 	switch spec.GetSpec().(type) {
 	case *pb.TypeSpec_Basic_:
 		return translateBasicType(value, spec)
@@ -277,3 +287,18 @@ func translateBasicType(value *pb.Value, spec *pb.TypeSpec) (interface{}, error)
 
 	return nil, errors.New("unsupported type")
 }
+
+// decodeVarint parses the big-endian two's complement representation used by
+// the CQL varint and decimal types.
+func decodeVarint(b []byte) (*big.Int, error) {
+	if len(b) == 0 {
+		return nil, errors.New("no bytes to decode")
+	}
+
+	value := new(big.Int).SetBytes(b)
+	if b[0]&0x80 != 0 {
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+	}
+
+	return value, nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"testing/quick"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/inf.v0"
+)
+
+// varintVectors are CQL varint encodings covering the byte boundaries where
+// sign extension matters.
+var varintVectors = []struct {
+	value   string
+	encoded string
+}{
+	{"0", "00"},
+	{"1", "01"},
+	{"127", "7f"},
+	{"128", "0080"},
+	{"129", "0081"},
+	{"256", "0100"},
+	{"-1", "ff"},
+	{"-128", "80"},
+	{"-129", "ff7f"},
+	{"-256", "ff00"},
+	{"-32768", "8000"},
+	{"9223372036854775807", "7fffffffffffffff"},
+	{"-9223372036854775808", "8000000000000000"},
+	{"18446744073709551616", "010000000000000000"},
+	{"-18446744073709551616", "ff0000000000000000"},
+}
+
+func TestToVarInt_Vectors(t *testing.T) {
+	for _, tt := range varintVectors {
+		t.Run(tt.value, func(t *testing.T) {
+			expected, ok := new(big.Int).SetString(tt.value, 10)
+			require.True(t, ok)
+			encoded, err := hex.DecodeString(tt.encoded)
+			require.NoError(t, err)
+
+			value := FromVarInt(expected)
+			assert.Equal(t, encoded, value.GetVarint().GetValue())
+
+			decoded, err := ToVarInt(&pb.Value{Inner: &pb.Value_Varint{Varint: &pb.Varint{Value: encoded}}})
+			require.NoError(t, err)
+			assert.Equal(t, 0, expected.Cmp(decoded), "expected %s, got %s", expected, decoded)
+		})
+	}
+}
+
+func TestToVarInt_Empty(t *testing.T) {
+	_, err := ToVarInt(&pb.Value{Inner: &pb.Value_Varint{Varint: &pb.Varint{}}})
+	assert.Error(t, err)
+}
+
+func TestToDecimal_Vectors(t *testing.T) {
+	tests := []struct {
+		value   string
+		scale   uint32
+		encoded string
+	}{
+		{"1.1", 1, "0b"},
+		{"-1.23", 2, "85"},
+		{"0.00", 2, "00"},
+		{"123456789012345678901234.5", 1, "01056e0f36a6443de2df79"},
+		{"-123456789012345678901234.5", 1, "fefa91f0c959bbc21d2087"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			expected, ok := new(inf.Dec).SetString(tt.value)
+			require.True(t, ok)
+			encoded, err := hex.DecodeString(tt.encoded)
+			require.NoError(t, err)
+
+			value := FromDecimal(expected)
+			assert.Equal(t, tt.scale, value.GetDecimal().GetScale())
+			assert.Equal(t, encoded, value.GetDecimal().GetValue())
+
+			decoded, err := ToDecimal(&pb.Value{Inner: &pb.Value_Decimal{Decimal: &pb.Decimal{Scale: tt.scale, Value: encoded}}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.value, decoded.String())
+		})
+	}
+}
+
+func TestVarInt_RoundTrip(t *testing.T) {
+	roundTrip := func(b []byte, negative bool) bool {
+		expected := new(big.Int).SetBytes(b)
+		if negative {
+			expected.Neg(expected)
+		}
+
+		decoded, err := ToVarInt(FromVarInt(expected))
+		return err == nil && expected.Cmp(decoded) == 0
+	}
+
+	assert.NoError(t, quick.Check(roundTrip, nil))
+}
+
+func TestDecimal_RoundTrip(t *testing.T) {
+	roundTrip := func(b []byte, negative bool, scale int32) bool {
+		unscaled := new(big.Int).SetBytes(b)
+		if negative {
+			unscaled.Neg(unscaled)
+		}
+		expected := inf.NewDecBig(unscaled, inf.Scale(scale))
+
+		decoded, err := ToDecimal(FromDecimal(expected))
+		return err == nil && expected.Cmp(decoded) == 0 && expected.Scale() == decoded.Scale()
+	}
+
+	assert.NoError(t, quick.Check(roundTrip, nil))
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// WarningHandler is called with the warnings the server returned for a query
+// or batch, such as tombstone or batch size warnings. cql is the statement, or
+// the statements of a batch separated by "; ".
+type WarningHandler func(ctx context.Context, kind RequestKind, cql string, warnings []string)
+
+// LogWarnings is a WarningHandler logging each warning at warn level, along
+// with the statement with its literals replaced by ?. This is the default.
+func LogWarnings(ctx context.Context, kind RequestKind, cql string, warnings []string) {
+	entry := log.WithContext(ctx).WithFields(log.Fields{"kind": kind, "cql": sanitizeCQL(cql)})
+	for _, warning := range warnings {
+		entry.Warnf("server warning: %s", warning)
+	}
+}
+
+// WithWarningHandler returns a StargateClientOption which sets the handler
+// called with the warnings returned for queries and batches. Warnings are
+// ignored if handler is nil.
+func WithWarningHandler(handler WarningHandler) StargateClientOption {
+	return func(c *StargateClient) {
+		c.warningHandler = handler
+	}
+}
+
+func (s *StargateClient) handleQueryWarnings(ctx context.Context, query *pb.Query, resp *pb.Response) {
+	if len(resp.GetWarnings()) == 0 {
+		return
+	}
+	s.handleWarnings(ctx, KindQuery, query.GetCql(), resp.GetWarnings())
+}
+
+func (s *StargateClient) handleBatchWarnings(ctx context.Context, batch *pb.Batch, resp *pb.Response) {
+	if len(resp.GetWarnings()) == 0 {
+		return
+	}
+
+	statements := make([]string, len(batch.GetQueries()))
+	for i, query := range batch.GetQueries() {
+		statements[i] = query.GetCql()
+	}
+	s.handleWarnings(ctx, KindBatch, strings.Join(statements, "; "), resp.GetWarnings())
+}
+
+func (s *StargateClient) handleWarnings(ctx context.Context, kind RequestKind, cql string, warnings []string) {
+	s.metrics.ServerWarnings(kind, sanitizeCQL(cql), len(warnings))
+	if s.warningHandler != nil {
+		s.warningHandler(ctx, kind, cql, warnings)
+	}
+}
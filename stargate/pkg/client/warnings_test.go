@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type handledWarnings struct {
+	kind     RequestKind
+	cql      string
+	warnings []string
+}
+
+func warningStargate(warnings ...string) *fakeStargate {
+	return &fakeStargate{
+		executeQuery: func(context.Context, *pb.Query) (*pb.Response, error) {
+			return &pb.Response{Warnings: warnings}, nil
+		},
+		executeBatch: func(context.Context, *pb.Batch) (*pb.Response, error) {
+			return &pb.Response{Warnings: warnings}, nil
+		},
+	}
+}
+
+func TestWarningHandler(t *testing.T) {
+	var handled []handledWarnings
+	metrics := &recordingMetrics{}
+	s := newFakeClient(warningStargate("Read 1000 live rows and 5000 tombstone cells"),
+		WithMetrics(metrics),
+		WithWarningHandler(func(_ context.Context, kind RequestKind, cql string, warnings []string) {
+			handled = append(handled, handledWarnings{kind, cql, warnings})
+		}),
+	)
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM events WHERE day = '2021-06-01'"})
+	require.NoError(t, err)
+	_, err = s.ExecuteBatch(&pb.Batch{Queries: []*pb.BatchQuery{
+		{Cql: "INSERT INTO t (k) VALUES (1)"},
+		{Cql: "INSERT INTO t (k) VALUES (2)"},
+	}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []handledWarnings{
+		{KindQuery, "SELECT * FROM events WHERE day = '2021-06-01'", []string{"Read 1000 live rows and 5000 tombstone cells"}},
+		{KindBatch, "INSERT INTO t (k) VALUES (1); INSERT INTO t (k) VALUES (2)", []string{"Read 1000 live rows and 5000 tombstone cells"}},
+	}, handled)
+	assert.Contains(t, metrics.calls, `warnings query "SELECT * FROM events WHERE day = ?" 1`)
+	assert.Contains(t, metrics.calls, `warnings batch "INSERT INTO t (k) VALUES (?); INSERT INTO t (k) VALUES (?)" 1`)
+}
+
+func TestWarningHandler_NoWarnings(t *testing.T) {
+	metrics := &recordingMetrics{}
+	s := newFakeClient(warningStargate(), WithMetrics(metrics), WithWarningHandler(
+		func(context.Context, RequestKind, string, []string) {
+			t.Fatal("unexpected warnings")
+		}))
+
+	_, err := s.ExecuteQuery(&pb.Query{Cql: "SELECT * FROM t"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`started query`, `finished query ""`}, metrics.calls)
+}
+
+func TestLogWarnings(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	s := newFakeClient(warningStargate("Batch is of size 6000, exceeding specified threshold of 5120"))
+	_, err := s.ExecuteBatch(&pb.Batch{Queries: []*pb.BatchQuery{{Cql: "INSERT INTO t (k) VALUES (1)"}}})
+	require.NoError(t, err)
+
+	require.Len(t, hook.Entries, 1)
+	entry := hook.LastEntry()
+	assert.Equal(t, logrus.WarnLevel, entry.Level)
+	assert.Equal(t, "server warning: Batch is of size 6000, exceeding specified threshold of 5120", entry.Message)
+	assert.Equal(t, "INSERT INTO t (k) VALUES (?)", entry.Data["cql"])
+	assert.Equal(t, KindBatch, entry.Data["kind"])
+}
@@ -0,0 +1,446 @@
+// Package config loads client settings from YAML or JSON files and STARGATE_*
+// environment variables, and turns them into a client.Config.
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/auth"
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Auth modes.
+const (
+	AuthNone  = "none"
+	AuthToken = "token"
+	AuthTable = "table"
+)
+
+// Retry policies.
+const (
+	RetryNone        = "none"
+	RetryDefault     = "default"
+	RetryExponential = "exponential"
+)
+
+// Load balancing policies.
+const (
+	LoadBalancingRoundRobin       = "round_robin"
+	LoadBalancingLeastOutstanding = "least_outstanding"
+)
+
+// Settings are the client settings read from a file and the environment. Each
+// field can be overridden by the environment variable named in its env tag.
+type Settings struct {
+	Endpoint      string        `yaml:"endpoint" env:"STARGATE_ENDPOINT"`
+	Endpoints     []string      `yaml:"endpoints" env:"STARGATE_ENDPOINTS"`
+	Datacenters   []Datacenter  `yaml:"datacenters"`
+	LoadBalancing LoadBalancing `yaml:"load_balancing"`
+	Insecure      bool          `yaml:"insecure" env:"STARGATE_INSECURE"`
+	TLS           TLS           `yaml:"tls"`
+	Auth          Auth          `yaml:"auth"`
+
+	Keyspace    string        `yaml:"keyspace" env:"STARGATE_KEYSPACE"`
+	Consistency string        `yaml:"consistency" env:"STARGATE_CONSISTENCY"`
+	PageSize    int32         `yaml:"page_size" env:"STARGATE_PAGE_SIZE"`
+	Timeout     time.Duration `yaml:"timeout" env:"STARGATE_TIMEOUT"`
+	Retry       Retry         `yaml:"retry"`
+}
+
+// TLS are the files and settings used to secure the connection.
+type TLS struct {
+	CAFile             string `yaml:"ca_file" env:"STARGATE_TLS_CA_FILE"`
+	CertFile           string `yaml:"cert_file" env:"STARGATE_TLS_CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"STARGATE_TLS_KEY_FILE"`
+	ServerName         string `yaml:"server_name" env:"STARGATE_TLS_SERVER_NAME"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"STARGATE_TLS_INSECURE_SKIP_VERIFY"`
+}
+
+// Auth selects how requests are authenticated. If Mode is empty it is
+// inferred from the other fields.
+type Auth struct {
+	Mode      string `yaml:"mode" env:"STARGATE_AUTH_MODE"`
+	Token     string `yaml:"token" env:"STARGATE_TOKEN"`
+	TokenFile string `yaml:"token_file" env:"STARGATE_TOKEN_FILE"`
+	URL       string `yaml:"url" env:"STARGATE_AUTH_URL"`
+	Username  string `yaml:"username" env:"STARGATE_USERNAME"`
+	Password  string `yaml:"password" env:"STARGATE_PASSWORD"`
+}
+
+// Datacenter is a named group of endpoints in the same datacenter.
+type Datacenter struct {
+	Name      string   `yaml:"name"`
+	Endpoints []string `yaml:"endpoints"`
+}
+
+// LoadBalancing selects how requests are spread across several endpoints,
+// when an endpoint is taken out of rotation and which datacenter is preferred.
+type LoadBalancing struct {
+	Policy           string        `yaml:"policy" env:"STARGATE_LOAD_BALANCING"`
+	FailureThreshold int           `yaml:"failure_threshold" env:"STARGATE_FAILURE_THRESHOLD"`
+	MinProbeBackoff  time.Duration `yaml:"min_probe_backoff" env:"STARGATE_MIN_PROBE_BACKOFF"`
+	MaxProbeBackoff  time.Duration `yaml:"max_probe_backoff" env:"STARGATE_MAX_PROBE_BACKOFF"`
+	// LocalDatacenter is the datacenter requests are sent to first, the
+	// first one listed if empty.
+	LocalDatacenter string `yaml:"local_datacenter" env:"STARGATE_LOCAL_DATACENTER"`
+	// LocalConsistencyFailover allows requests at a LOCAL_* consistency
+	// level to fail over to a remote datacenter.
+	LocalConsistencyFailover bool `yaml:"local_consistency_failover" env:"STARGATE_LOCAL_CONSISTENCY_FAILOVER"`
+}
+
+// Retry selects the retry policy.
+type Retry struct {
+	Policy     string        `yaml:"policy" env:"STARGATE_RETRY_POLICY"`
+	MaxRetries int           `yaml:"max_retries" env:"STARGATE_RETRY_MAX_RETRIES"`
+	MinDelay   time.Duration `yaml:"min_delay" env:"STARGATE_RETRY_MIN_DELAY"`
+	MaxDelay   time.Duration `yaml:"max_delay" env:"STARGATE_RETRY_MAX_DELAY"`
+}
+
+// Load reads settings from the YAML or JSON file at path, applies any
+// STARGATE_* environment variables and validates the result. If path is empty
+// only the environment is used.
+func Load(path string) (*Settings, error) {
+	s := &Settings{}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		defer f.Close()
+
+		if err := s.decode(f); err != nil {
+			return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(s).Elem()); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// decode reads YAML, or JSON since it is a subset of YAML, rejecting unknown
+// fields.
+func (s *Settings) decode(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(s); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// applyEnv overrides the fields of v with the environment variables named by
+// their env tags.
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := t.Field(i).Tag.Get("env")
+		value, ok := os.LookupEnv(name)
+		if name == "" || !ok {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case int, int32:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case []string:
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Validate checks that the settings are complete and consistent, reporting
+// every problem found.
+func (s *Settings) Validate() error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Datacenters) > 0 {
+		if len(s.endpoints()) > 0 {
+			problem("endpoints cannot be configured together with datacenters")
+		}
+		names := make(map[string]bool)
+		for _, dc := range s.Datacenters {
+			switch {
+			case dc.Name == "":
+				problem("datacenters[].name is required")
+			case names[dc.Name]:
+				problem("datacenter %s is listed more than once", dc.Name)
+			case len(dc.Endpoints) == 0:
+				problem("datacenter %s has no endpoints", dc.Name)
+			}
+			names[dc.Name] = true
+		}
+		if local := s.LoadBalancing.LocalDatacenter; local != "" && !names[local] {
+			problem("load_balancing.local_datacenter %q is not one of the datacenters", local)
+		}
+	} else if len(s.endpoints()) == 0 {
+		problem("endpoint is required")
+	}
+
+	switch s.LoadBalancing.Policy {
+	case "", LoadBalancingRoundRobin, LoadBalancingLeastOutstanding:
+	default:
+		problem("load_balancing.policy %q is not one of %s or %s",
+			s.LoadBalancing.Policy, LoadBalancingRoundRobin, LoadBalancingLeastOutstanding)
+	}
+	if s.LoadBalancing.FailureThreshold < 0 {
+		problem("load_balancing.failure_threshold must not be negative")
+	}
+	if s.LoadBalancing.MaxProbeBackoff != 0 && s.LoadBalancing.MaxProbeBackoff < s.LoadBalancing.MinProbeBackoff {
+		problem("load_balancing.max_probe_backoff must not be less than load_balancing.min_probe_backoff")
+	}
+
+	tlsSet := s.TLS != (TLS{})
+	if s.Insecure && tlsSet {
+		problem("tls cannot be configured when insecure is true")
+	}
+	if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+		problem("tls.cert_file and tls.key_file must be set together")
+	}
+
+	switch s.authMode() {
+	case AuthNone:
+	case AuthToken:
+		if s.Auth.Token == "" && s.Auth.TokenFile == "" {
+			problem("auth.token or auth.token_file is required for token auth")
+		}
+	case AuthTable:
+		if s.Auth.URL == "" {
+			problem("auth.url is required for table auth")
+		}
+		if s.Auth.Username == "" {
+			problem("auth.username is required for table auth")
+		}
+	default:
+		problem("auth.mode %q is not one of %s, %s or %s", s.Auth.Mode, AuthNone, AuthToken, AuthTable)
+	}
+
+	if s.Consistency != "" {
+		if _, ok := pb.Consistency_value[strings.ToUpper(s.Consistency)]; !ok {
+			problem("consistency %q is not a valid consistency level", s.Consistency)
+		}
+	}
+	if s.PageSize < 0 {
+		problem("page_size must not be negative")
+	}
+	if s.Timeout < 0 {
+		problem("timeout must not be negative")
+	}
+
+	switch s.Retry.Policy {
+	case "", RetryNone, RetryDefault:
+	case RetryExponential:
+		if s.Retry.MaxRetries <= 0 {
+			problem("retry.max_retries must be positive for exponential retries")
+		}
+		if s.Retry.MaxDelay < s.Retry.MinDelay {
+			problem("retry.max_delay must not be less than retry.min_delay")
+		}
+	default:
+		problem("retry.policy %q is not one of %s, %s or %s", s.Retry.Policy, RetryNone, RetryDefault, RetryExponential)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *Settings) endpoints() []string {
+	if s.Endpoint != "" {
+		return append([]string{s.Endpoint}, s.Endpoints...)
+	}
+	return s.Endpoints
+}
+
+func (s *Settings) authMode() string {
+	switch {
+	case s.Auth.Mode != "":
+		return s.Auth.Mode
+	case s.Auth.Token != "" || s.Auth.TokenFile != "":
+		return AuthToken
+	case s.Auth.Username != "":
+		return AuthTable
+	}
+	return AuthNone
+}
+
+// ClientConfig returns the client.Config described by the settings, reading
+// any TLS files.
+func (s *Settings) ClientConfig() (client.Config, error) {
+	if err := s.Validate(); err != nil {
+		return client.Config{}, err
+	}
+
+	config := client.Config{
+		FailureThreshold:         s.LoadBalancing.FailureThreshold,
+		MinProbeBackoff:          s.LoadBalancing.MinProbeBackoff,
+		MaxProbeBackoff:          s.LoadBalancing.MaxProbeBackoff,
+		LocalDatacenter:          s.LoadBalancing.LocalDatacenter,
+		LocalConsistencyFailover: s.LoadBalancing.LocalConsistencyFailover,
+		Insecure:                 s.Insecure,
+	}
+	if endpoints := s.endpoints(); len(endpoints) > 0 {
+		config.Endpoint, config.Endpoints = endpoints[0], endpoints[1:]
+	}
+	for _, dc := range s.Datacenters {
+		config.Datacenters = append(config.Datacenters, client.Datacenter{Name: dc.Name, Endpoints: dc.Endpoints})
+	}
+	if s.LoadBalancing.Policy == LoadBalancingLeastOutstanding {
+		config.LoadBalancing = client.LeastOutstanding
+	}
+
+	if s.TLS != (TLS{}) {
+		tlsConfig, err := s.TLS.config()
+		if err != nil {
+			return client.Config{}, err
+		}
+		config.TLS = tlsConfig
+	}
+
+	switch s.authMode() {
+	case AuthToken:
+		if s.Auth.TokenFile != "" {
+			if s.Insecure {
+				config.Credentials = auth.NewFileTokenProviderUnsafe(s.Auth.TokenFile)
+			} else {
+				config.Credentials = auth.NewFileTokenProvider(s.Auth.TokenFile)
+			}
+		} else {
+			config.Token = s.Auth.Token
+		}
+	case AuthTable:
+		config.AuthURL, config.Username, config.Password = s.Auth.URL, s.Auth.Username, s.Auth.Password
+	}
+
+	if s.Keyspace != "" {
+		config.ClientOptions = append(config.ClientOptions, client.WithDefaultKeyspace(s.Keyspace))
+	}
+	if s.Consistency != "" {
+		consistency := pb.Consistency(pb.Consistency_value[strings.ToUpper(s.Consistency)])
+		config.ClientOptions = append(config.ClientOptions, client.WithDefaultConsistency(consistency))
+	}
+	if s.PageSize > 0 {
+		config.ClientOptions = append(config.ClientOptions, client.WithDefaultPageSize(s.PageSize))
+	}
+	if s.Timeout > 0 {
+		config.ClientOptions = append(config.ClientOptions, client.WithTimeout(s.Timeout))
+	}
+	switch s.Retry.Policy {
+	case RetryDefault:
+		config.ClientOptions = append(config.ClientOptions, client.WithRetryPolicy(client.NewDefaultRetryPolicy()))
+	case RetryExponential:
+		config.ClientOptions = append(config.ClientOptions, client.WithRetryPolicy(
+			client.NewExponentialBackoffRetryPolicy(s.Retry.MaxRetries, s.Retry.MinDelay, s.Retry.MaxDelay)))
+	}
+
+	return config, nil
+}
+
+func (t TLS) config() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls.ca_file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in tls.ca_file %s", t.CAFile)
+		}
+	}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls.cert_file and tls.key_file: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// Dial loads the settings at path, see Load, and connects to Stargate.
+func Dial(ctx context.Context, path string) (*client.StargateClient, error) {
+	s, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return client.Dial(ctx, config)
+}
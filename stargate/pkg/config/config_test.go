@@ -0,0 +1,238 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestLoad_YAML(t *testing.T) {
+	path := writeFile(t, "stargate.yaml", `
+endpoint: stargate.example.com:443
+auth:
+  url: https://stargate.example.com/v1/auth
+  username: cassandra
+  password: cassandra
+keyspace: app
+consistency: local_quorum
+page_size: 500
+timeout: 5s
+retry:
+  policy: exponential
+  max_retries: 3
+  min_delay: 50ms
+  max_delay: 1s
+`)
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, &Settings{
+		Endpoint: "stargate.example.com:443",
+		Auth: Auth{
+			URL:      "https://stargate.example.com/v1/auth",
+			Username: "cassandra",
+			Password: "cassandra",
+		},
+		Keyspace:    "app",
+		Consistency: "local_quorum",
+		PageSize:    500,
+		Timeout:     5 * time.Second,
+		Retry: Retry{
+			Policy:     RetryExponential,
+			MaxRetries: 3,
+			MinDelay:   50 * time.Millisecond,
+			MaxDelay:   time.Second,
+		},
+	}, s)
+
+	config, err := s.ClientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "stargate.example.com:443", config.Endpoint)
+	assert.False(t, config.Insecure)
+	assert.Equal(t, "cassandra", config.Username)
+	assert.Len(t, config.ClientOptions, 5)
+}
+
+func TestLoad_JSON(t *testing.T) {
+	path := writeFile(t, "stargate.json", `{
+		"endpoints": ["localhost:8090", "localhost:8091"],
+		"load_balancing": {"policy": "least_outstanding", "failure_threshold": 5},
+		"insecure": true,
+		"auth": {"token": "secret"},
+		"timeout": "2s"
+	}`)
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost:8090", "localhost:8091"}, s.Endpoints)
+	assert.Equal(t, 2*time.Second, s.Timeout)
+
+	config, err := s.ClientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:8090", config.Endpoint)
+	assert.Equal(t, []string{"localhost:8091"}, config.Endpoints)
+	assert.Equal(t, client.LeastOutstanding, config.LoadBalancing)
+	assert.Equal(t, 5, config.FailureThreshold)
+	assert.True(t, config.Insecure)
+	assert.Equal(t, "secret", config.Token)
+}
+
+func TestLoad_Datacenters(t *testing.T) {
+	path := writeFile(t, "stargate.yaml", `
+datacenters:
+  - name: us-east
+    endpoints: [east-1:8090, east-2:8090]
+  - name: eu-west
+    endpoints: [west-1:8090]
+load_balancing:
+  local_datacenter: eu-west
+`)
+	t.Setenv("STARGATE_LOCAL_CONSISTENCY_FAILOVER", "true")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+
+	config, err := s.ClientConfig()
+	require.NoError(t, err)
+	assert.Empty(t, config.Endpoint)
+	assert.Equal(t, []client.Datacenter{
+		{Name: "us-east", Endpoints: []string{"east-1:8090", "east-2:8090"}},
+		{Name: "eu-west", Endpoints: []string{"west-1:8090"}},
+	}, config.Datacenters)
+	assert.Equal(t, "eu-west", config.LocalDatacenter)
+	assert.True(t, config.LocalConsistencyFailover)
+}
+
+func TestLoad_EnvOverrides(t *testing.T) {
+	path := writeFile(t, "stargate.yaml", `
+endpoint: localhost:8090
+insecure: true
+keyspace: dev
+`)
+	t.Setenv("STARGATE_ENDPOINT", "")
+	t.Setenv("STARGATE_ENDPOINTS", "prod:8090")
+	t.Setenv("STARGATE_KEYSPACE", "prod")
+	t.Setenv("STARGATE_TOKEN", "secret")
+	t.Setenv("STARGATE_PAGE_SIZE", "100")
+	t.Setenv("STARGATE_RETRY_POLICY", "default")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod:8090"}, s.endpoints())
+	assert.Equal(t, "prod", s.Keyspace)
+	assert.Equal(t, "secret", s.Auth.Token)
+	assert.Equal(t, int32(100), s.PageSize)
+	assert.Equal(t, RetryDefault, s.Retry.Policy)
+	assert.True(t, s.Insecure)
+}
+
+func TestLoad_EnvOnly(t *testing.T) {
+	t.Setenv("STARGATE_ENDPOINT", "localhost:8090")
+	t.Setenv("STARGATE_INSECURE", "true")
+
+	s, err := Load("")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:8090", s.Endpoint)
+	assert.True(t, s.Insecure)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		env      map[string]string
+		err      string
+	}{
+		{
+			"unknown field",
+			"endpoint: localhost:8090\nkeyspce: app\n",
+			nil,
+			"field keyspce not found",
+		},
+		{
+			"invalid env",
+			"endpoint: localhost:8090\n",
+			map[string]string{"STARGATE_TIMEOUT": "soon"},
+			`invalid value "soon" for STARGATE_TIMEOUT`,
+		},
+		{
+			"every problem reported",
+			"insecure: true\ntls: {ca_file: ca.pem}\nconsistency: MOST\nauth: {mode: table}\nretry: {policy: exponential}\n",
+			nil,
+			"invalid config: endpoint is required; tls cannot be configured when insecure is true; " +
+				"auth.url is required for table auth; auth.username is required for table auth; " +
+				`consistency "MOST" is not a valid consistency level; ` +
+				"retry.max_retries must be positive for exponential retries",
+		},
+		{
+			"unknown load balancing policy",
+			"endpoints: [a:8090, b:8090]\nload_balancing: {policy: random}\n",
+			nil,
+			`load_balancing.policy "random" is not one of round_robin or least_outstanding`,
+		},
+		{
+			"unknown local datacenter",
+			"datacenters: [{name: dc1, endpoints: [a:8090]}]\nload_balancing: {local_datacenter: dc2}\n",
+			nil,
+			`load_balancing.local_datacenter "dc2" is not one of the datacenters`,
+		},
+		{
+			"endpoints and datacenters",
+			"endpoint: a:8090\ndatacenters: [{name: dc1, endpoints: [b:8090]}]\n",
+			nil,
+			"endpoints cannot be configured together with datacenters",
+		},
+		{
+			"unknown auth mode",
+			"endpoint: localhost:8090\nauth: {mode: kerberos}\n",
+			nil,
+			`auth.mode "kerberos" is not one of none, token or table`,
+		},
+		{
+			"cert without key",
+			"endpoint: localhost:8090\ntls: {cert_file: cert.pem}\n",
+			nil,
+			"tls.cert_file and tls.key_file must be set together",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(writeFile(t, "stargate.yaml", tt.contents))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestClientConfig_TLSFiles(t *testing.T) {
+	s := &Settings{Endpoint: "localhost:8090", TLS: TLS{CAFile: filepath.Join(t.TempDir(), "missing.pem")}}
+	_, err := s.ClientConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read tls.ca_file")
+
+	s.TLS.CAFile = writeFile(t, "ca.pem", "not a certificate")
+	_, err = s.ClientConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no certificates found in tls.ca_file")
+
+	s.TLS = TLS{ServerName: "stargate.internal"}
+	config, err := s.ClientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "stargate.internal", config.TLS.ServerName)
+}
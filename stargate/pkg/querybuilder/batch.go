@@ -0,0 +1,370 @@
+package querybuilder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultBatchMaxSize matches Cassandra's default batch size warning
+	// threshold of 5KiB.
+	DefaultBatchMaxSize = 5 * 1024
+	// DefaultBatchMaxPartitions matches Cassandra's default warning threshold
+	// for unlogged batches spanning many partitions.
+	DefaultBatchMaxPartitions = 10
+)
+
+// BatchBuilder accumulates statements into a *pb.Batch, choosing the batch
+// type and guarding against batches that are too large or span too many
+// partitions.
+type BatchBuilder struct {
+	statements    []batchStatement
+	batchType     pb.Batch_Type
+	typeSet       bool
+	parameters    *pb.BatchParameters
+	partitionKey  []string
+	maxSize       int
+	maxPartitions int
+	warn          func(message string)
+	err           error
+}
+
+type batchStatement struct {
+	query     *pb.BatchQuery
+	size      int
+	partition string
+	counter   bool
+}
+
+// Batch starts a new batch.
+func Batch() *BatchBuilder {
+	return &BatchBuilder{
+		maxSize:       DefaultBatchMaxSize,
+		maxPartitions: DefaultBatchMaxPartitions,
+		warn:          logWarning,
+	}
+}
+
+func logWarning(message string) {
+	log.Warn(message)
+}
+
+// Add adds INSERT, UPDATE and DELETE statements to the batch.
+func (b *BatchBuilder) Add(statements ...Builder) *BatchBuilder {
+	for _, stmt := range statements {
+		if _, ok := stmt.(*SelectBuilder); ok {
+			b.setErr(errors.New("batch cannot contain select statements"))
+			continue
+		}
+
+		query, err := stmt.Build()
+		if err != nil {
+			b.setErr(err)
+			continue
+		}
+		b.add(query, b.partitionOf(stmt), isCounterUpdate(stmt))
+	}
+	return b
+}
+
+// AddQuery adds a statement that was not built with this package. Its
+// partition is unknown and it is assumed not to be a counter update.
+func (b *BatchBuilder) AddQuery(query *pb.Query) *BatchBuilder {
+	b.add(query, "", false)
+	return b
+}
+
+func (b *BatchBuilder) add(query *pb.Query, partition string, counter bool) {
+	bq := &pb.BatchQuery{Cql: query.GetCql(), Values: query.GetValues()}
+	b.statements = append(b.statements, batchStatement{
+		query:     bq,
+		size:      proto.Size(bq),
+		partition: partition,
+		counter:   counter,
+	})
+}
+
+func (b *BatchBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// PartitionKey names the partition key columns of the batched tables, which
+// allows the partition each statement writes to be determined from its
+// values. Without it every partition is unknown and the batch is never
+// considered to span a single partition.
+func (b *BatchBuilder) PartitionKey(columns ...string) *BatchBuilder {
+	b.partitionKey = columns
+	return b
+}
+
+// Type sets the batch type rather than choosing it automatically. By default
+// a batch of counter updates is COUNTER, a batch writing to a single known
+// partition is UNLOGGED, and any other batch is LOGGED.
+func (b *BatchBuilder) Type(batchType pb.Batch_Type) *BatchBuilder {
+	b.batchType, b.typeSet = batchType, true
+	return b
+}
+
+// Parameters sets the parameters of the batch.
+func (b *BatchBuilder) Parameters(parameters *pb.BatchParameters) *BatchBuilder {
+	b.parameters = parameters
+	return b
+}
+
+// MaxSize sets the serialized size in bytes above which a batch is reported as
+// a warning by Build or split by Split. Zero disables the check. The default is
+// DefaultBatchMaxSize.
+func (b *BatchBuilder) MaxSize(bytes int) *BatchBuilder {
+	b.maxSize = bytes
+	return b
+}
+
+// MaxPartitions sets the number of known partitions above which a batch is
+// reported as a warning by Build or split by Split. Zero disables the check. The
+// default is DefaultBatchMaxPartitions.
+func (b *BatchBuilder) MaxPartitions(partitions int) *BatchBuilder {
+	b.maxPartitions = partitions
+	return b
+}
+
+// OnWarning sets the function Build passes a warning to when the batch exceeds
+// the size or partition thresholds. Warnings are logged with logrus by
+// default, and ignored if warn is nil.
+func (b *BatchBuilder) OnWarning(warn func(message string)) *BatchBuilder {
+	b.warn = warn
+	return b
+}
+
+// Build returns a single batch containing every statement, reporting a warning
+// to the OnWarning function if it exceeds the size or partition thresholds.
+func (b *BatchBuilder) Build() (*pb.Batch, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+
+	size, partitions := measure(b.statements)
+	if b.maxSize > 0 && size > b.maxSize {
+		b.warnf("batch of %d statements is %d bytes, exceeding the threshold of %d bytes",
+			len(b.statements), size, b.maxSize)
+	}
+	if b.maxPartitions > 0 && partitions > b.maxPartitions {
+		b.warnf("batch of %d statements spans %d partitions, exceeding the threshold of %d partitions",
+			len(b.statements), partitions, b.maxPartitions)
+	}
+
+	return b.batch(b.statements)
+}
+
+// Split returns the statements split, in order, into as few batches as
+// possible that stay within the size and partition thresholds. Unless the
+// batch type is set, counter updates are batched separately from other
+// statements, after them. A statement that exceeds the size threshold on its
+// own is placed in a batch by itself. The batches are executed independently,
+// so the statements are no longer applied atomically.
+func (b *BatchBuilder) Split() ([]*pb.Batch, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+
+	groups := [][]batchStatement{b.statements}
+	if !b.typeSet {
+		var counters, others []batchStatement
+		for _, stmt := range b.statements {
+			if stmt.counter {
+				counters = append(counters, stmt)
+			} else {
+				others = append(others, stmt)
+			}
+		}
+		groups = [][]batchStatement{others, counters}
+	}
+
+	var batches []*pb.Batch
+	for _, statements := range groups {
+		start := 0
+		for end := 1; end <= len(statements); end++ {
+			if end < len(statements) && b.fits(statements[start:end+1]) {
+				continue
+			}
+			batch, err := b.batch(statements[start:end])
+			if err != nil {
+				return nil, err
+			}
+			batches = append(batches, batch)
+			start = end
+		}
+	}
+
+	return batches, nil
+}
+
+func (b *BatchBuilder) warnf(format string, args ...interface{}) {
+	if b.warn != nil {
+		b.warn(fmt.Sprintf(format, args...))
+	}
+}
+
+func (b *BatchBuilder) check() error {
+	if b.err != nil {
+		return b.err
+	}
+	if len(b.statements) == 0 {
+		return errors.New("batch requires at least one statement")
+	}
+	return nil
+}
+
+func (b *BatchBuilder) fits(statements []batchStatement) bool {
+	size, partitions := measure(statements)
+	return (b.maxSize <= 0 || size <= b.maxSize) && (b.maxPartitions <= 0 || partitions <= b.maxPartitions)
+}
+
+func (b *BatchBuilder) batch(statements []batchStatement) (*pb.Batch, error) {
+	batchType, err := b.typeOf(statements)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &pb.Batch{Type: batchType}
+	for _, stmt := range statements {
+		batch.Queries = append(batch.Queries, stmt.query)
+	}
+	if b.parameters != nil {
+		batch.Parameters = proto.Clone(b.parameters).(*pb.BatchParameters)
+	}
+	return batch, nil
+}
+
+func (b *BatchBuilder) typeOf(statements []batchStatement) (pb.Batch_Type, error) {
+	if b.typeSet {
+		return b.batchType, nil
+	}
+
+	counters := 0
+	for _, stmt := range statements {
+		if stmt.counter {
+			counters++
+		}
+	}
+	switch {
+	case counters == len(statements):
+		return pb.Batch_COUNTER, nil
+	case counters > 0:
+		return 0, errors.New("batch cannot mix counter and non-counter updates")
+	}
+
+	partition := statements[0].partition
+	for _, stmt := range statements {
+		if stmt.partition == "" || stmt.partition != partition {
+			return pb.Batch_LOGGED, nil
+		}
+	}
+	return pb.Batch_UNLOGGED, nil
+}
+
+// measure returns the total serialized size of statements and the number of
+// distinct known partitions they write to.
+func measure(statements []batchStatement) (int, int) {
+	size := 0
+	partitions := map[string]struct{}{}
+	for _, stmt := range statements {
+		size += stmt.size
+		if stmt.partition != "" {
+			partitions[stmt.partition] = struct{}{}
+		}
+	}
+	return size, len(partitions)
+}
+
+// partitionOf returns a key identifying the partition stmt writes to, or an
+// empty string if it cannot be determined from the partition key values.
+func (b *BatchBuilder) partitionOf(stmt Builder) string {
+	if len(b.partitionKey) == 0 {
+		return ""
+	}
+
+	var table string
+	values := map[string]interface{}{}
+	switch s := stmt.(type) {
+	case *InsertBuilder:
+		table = tableName(s.keyspace, s.table)
+		for i, column := range s.columns {
+			values[column] = s.values[i]
+		}
+	case *UpdateBuilder:
+		table = tableName(s.keyspace, s.table)
+		equalities(s.where, values)
+	case *DeleteBuilder:
+		table = tableName(s.keyspace, s.table)
+		equalities(s.where, values)
+	default:
+		return ""
+	}
+
+	var key strings.Builder
+	key.WriteString(table)
+	for _, column := range b.partitionKey {
+		value, ok := values[column]
+		if !ok {
+			return ""
+		}
+		if _, raw := value.(Raw); raw {
+			return ""
+		}
+		v, err := client.FromGo(value, nil)
+		if err != nil {
+			return ""
+		}
+		encoded, err := proto.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		fmt.Fprintf(&key, "/%x", encoded)
+	}
+	return key.String()
+}
+
+func equalities(relations []Relation, values map[string]interface{}) {
+	for _, r := range relations {
+		if r.op == "=" && r.lhs == QuoteIdentifier(r.name) {
+			values[r.name] = r.values[0]
+		}
+	}
+}
+
+// isCounterUpdate reports whether stmt only increments or decrements columns
+// by integer amounts.
+func isCounterUpdate(stmt Builder) bool {
+	update, ok := stmt.(*UpdateBuilder)
+	if !ok || len(update.assignments) == 0 {
+		return false
+	}
+
+	for _, a := range update.assignments {
+		if a.op != "+" && a.op != "-" {
+			return false
+		}
+		if v, ok := a.value.(*pb.Value); ok {
+			if _, isInt := v.GetInner().(*pb.Value_Int); !isInt {
+				return false
+			}
+			continue
+		}
+		switch reflect.ValueOf(a.value).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return false
+		}
+	}
+	return true
+}
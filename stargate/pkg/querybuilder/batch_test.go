@@ -0,0 +1,191 @@
+package querybuilder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch_Type(t *testing.T) {
+	tests := []struct {
+		name      string
+		batch     *BatchBuilder
+		batchType pb.Batch_Type
+	}{
+		{
+			"counter",
+			Batch().Add(
+				Update("ks", "counts").Add("views", 1).Where(Eq("id", 1)),
+				Update("ks", "counts").Remove("likes", client.FromInt(2)).Where(Eq("id", 2)),
+			),
+			pb.Batch_COUNTER,
+		},
+		{
+			"single partition",
+			Batch().PartitionKey("id").Add(
+				Insert("ks", "users").Value("id", 1).Value("name", "alice"),
+				Update("ks", "users").Set("email", "a@example.com").Where(Eq("id", 1)),
+				Delete("ks", "users").Columns("phone").Where(Eq("id", 1)),
+			),
+			pb.Batch_UNLOGGED,
+		},
+		{
+			"multiple partitions",
+			Batch().PartitionKey("id").Add(
+				Insert("ks", "users").Value("id", 1),
+				Insert("ks", "users").Value("id", 2),
+			),
+			pb.Batch_LOGGED,
+		},
+		{
+			"same key in different tables",
+			Batch().PartitionKey("id").Add(
+				Insert("ks", "users").Value("id", 1),
+				Insert("ks", "users_by_email").Value("id", 1),
+			),
+			pb.Batch_LOGGED,
+		},
+		{
+			"unknown partition",
+			Batch().Add(Insert("ks", "users").Value("id", 1)),
+			pb.Batch_LOGGED,
+		},
+		{
+			"collection append is not a counter",
+			Batch().Add(Update("ks", "users").Add("tags", []string{"a"}).Where(Eq("id", 1))),
+			pb.Batch_LOGGED,
+		},
+		{
+			"explicit",
+			Batch().Type(pb.Batch_UNLOGGED).AddQuery(&pb.Query{Cql: "INSERT INTO ks.users (id) VALUES (1)"}),
+			pb.Batch_UNLOGGED,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch, err := tt.batch.Build()
+			require.NoError(t, err)
+			assert.Equal(t, tt.batchType, batch.Type)
+		})
+	}
+}
+
+func TestBatch_Build(t *testing.T) {
+	params := &pb.BatchParameters{Consistency: &pb.ConsistencyValue{Value: pb.Consistency_QUORUM}}
+	batch, err := Batch().Parameters(params).
+		Add(Insert("ks", "users").Value("id", 1)).
+		AddQuery(&pb.Query{Cql: "DELETE FROM ks.users WHERE id = 2"}).
+		Build()
+	require.NoError(t, err)
+
+	require.Len(t, batch.Queries, 2)
+	assert.Equal(t, "INSERT INTO ks.users (id) VALUES (?)", batch.Queries[0].Cql)
+	assert.Equal(t, int64(1), batch.Queries[0].Values.Values[0].GetInt())
+	assert.Equal(t, "DELETE FROM ks.users WHERE id = 2", batch.Queries[1].Cql)
+	assert.Equal(t, pb.Consistency_QUORUM, batch.Parameters.Consistency.Value)
+}
+
+func TestBatch_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		batch *BatchBuilder
+		err   string
+	}{
+		{"empty", Batch(), "batch requires at least one statement"},
+		{"select", Batch().Add(Select("ks", "users")), "batch cannot contain select statements"},
+		{"invalid statement", Batch().Add(Update("ks", "users")), "update requires at least one assignment"},
+		{
+			"mixed counters",
+			Batch().Add(
+				Update("ks", "counts").Add("views", 1).Where(Eq("id", 1)),
+				Insert("ks", "users").Value("id", 1),
+			),
+			"batch cannot mix counter and non-counter updates",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.batch.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestBatch_SplitPartitions(t *testing.T) {
+	b := Batch().PartitionKey("id").MaxPartitions(2)
+	for _, id := range []int{1, 1, 2, 3, 3, 4, 5} {
+		b.Add(Insert("ks", "users").Value("id", id))
+	}
+
+	batches, err := b.Split()
+	require.NoError(t, err)
+
+	var sizes []int
+	for _, batch := range batches {
+		sizes = append(sizes, len(batch.Queries))
+	}
+	assert.Equal(t, []int{3, 3, 1}, sizes)
+	assert.Equal(t, pb.Batch_UNLOGGED, batches[2].Type)
+}
+
+func TestBatch_SplitSize(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	b := Batch().MaxSize(400).
+		Add(
+			Insert("ks", "users").Value("id", 1).Value("bio", long),
+			Insert("ks", "users").Value("id", 2).Value("bio", long),
+			Insert("ks", "users").Value("id", 3).Value("bio", strings.Repeat("x", 500)),
+			Insert("ks", "users").Value("id", 4).Value("bio", long),
+		)
+
+	batches, err := b.Split()
+	require.NoError(t, err)
+
+	var sizes []int
+	for _, batch := range batches {
+		sizes = append(sizes, len(batch.Queries))
+	}
+	assert.Equal(t, []int{2, 1, 1}, sizes)
+}
+
+func TestBatch_SplitCounters(t *testing.T) {
+	batches, err := Batch().Add(
+		Insert("ks", "users").Value("id", 1),
+		Update("ks", "counts").Add("views", 1).Where(Eq("id", 1)),
+		Insert("ks", "users").Value("id", 2),
+		Update("ks", "counts").Add("views", 1).Where(Eq("id", 2)),
+	).Split()
+	require.NoError(t, err)
+
+	require.Len(t, batches, 2)
+	assert.Equal(t, pb.Batch_LOGGED, batches[0].Type)
+	assert.Len(t, batches[0].Queries, 2)
+	assert.Equal(t, pb.Batch_COUNTER, batches[1].Type)
+	assert.Len(t, batches[1].Queries, 2)
+}
+
+func TestBatch_Warnings(t *testing.T) {
+	var warnings []string
+	_, err := Batch().PartitionKey("id").MaxSize(10).MaxPartitions(1).
+		OnWarning(func(message string) { warnings = append(warnings, message) }).
+		Add(
+			Insert("ks", "users").Value("id", 1),
+			Insert("ks", "users").Value("id", 2),
+		).
+		Build()
+	require.NoError(t, err)
+
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "exceeding the threshold of 10 bytes")
+	assert.Equal(t, "batch of 2 statements spans 2 partitions, exceeding the threshold of 1 partitions", warnings[1])
+
+	_, err = Batch().MaxSize(10).OnWarning(nil).Add(Insert("ks", "users").Value("id", 1)).Build()
+	assert.NoError(t, err)
+}
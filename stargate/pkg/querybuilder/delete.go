@@ -0,0 +1,88 @@
+package querybuilder
+
+import (
+	"errors"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// DeleteBuilder builds a DELETE statement.
+type DeleteBuilder struct {
+	keyspace   string
+	table      string
+	columns    []string
+	where      []Relation
+	conditions conditions
+	using      using
+}
+
+// Delete starts a DELETE statement on keyspace.table.
+func Delete(keyspace, table string) *DeleteBuilder {
+	return &DeleteBuilder{keyspace: keyspace, table: table}
+}
+
+// Columns deletes only the given columns rather than whole rows.
+func (b *DeleteBuilder) Columns(columns ...string) *DeleteBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// Where adds relations to the WHERE clause, joined with AND.
+func (b *DeleteBuilder) Where(relations ...Relation) *DeleteBuilder {
+	b.where = append(b.where, relations...)
+	return b
+}
+
+// If adds conditions to the IF clause, making the statement a lightweight
+// transaction.
+func (b *DeleteBuilder) If(relations ...Relation) *DeleteBuilder {
+	b.conditions.relations = append(b.conditions.relations, relations...)
+	return b
+}
+
+// IfExists only deletes the row if it exists.
+func (b *DeleteBuilder) IfExists() *DeleteBuilder {
+	b.conditions.exists = true
+	return b
+}
+
+// Timestamp sets the time of the deletion.
+func (b *DeleteBuilder) Timestamp(t time.Time) *DeleteBuilder {
+	b.using.timestamp, b.using.hasTimestamp = t, true
+	return b
+}
+
+func (b *DeleteBuilder) Build() (*pb.Query, error) {
+	return b.build(false)
+}
+
+func (b *DeleteBuilder) BuildNamed() (*pb.Query, error) {
+	return b.build(true)
+}
+
+func (b *DeleteBuilder) build(named bool) (*pb.Query, error) {
+	if b.table == "" {
+		return nil, errors.New("delete requires a table")
+	}
+	if len(b.where) == 0 {
+		return nil, errors.New("delete requires a where clause")
+	}
+
+	s := newStatement(named)
+	s.write("DELETE")
+	for i, column := range b.columns {
+		if i == 0 {
+			s.write(" ")
+		} else {
+			s.write(", ")
+		}
+		s.write(QuoteIdentifier(column))
+	}
+	s.write(" FROM ", tableName(b.keyspace, b.table))
+	b.using.write(s)
+	s.relations("WHERE", b.where)
+	b.conditions.write(s)
+
+	return s.query()
+}
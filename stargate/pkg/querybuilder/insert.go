@@ -0,0 +1,89 @@
+package querybuilder
+
+import (
+	"errors"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// InsertBuilder builds an INSERT statement.
+type InsertBuilder struct {
+	keyspace    string
+	table       string
+	columns     []string
+	values      []interface{}
+	ifNotExists bool
+	using       using
+}
+
+// Insert starts an INSERT statement on keyspace.table.
+func Insert(keyspace, table string) *InsertBuilder {
+	return &InsertBuilder{keyspace: keyspace, table: table}
+}
+
+// Value sets column to value.
+func (b *InsertBuilder) Value(column string, value interface{}) *InsertBuilder {
+	b.columns = append(b.columns, column)
+	b.values = append(b.values, value)
+	return b
+}
+
+// IfNotExists only inserts the row if it does not already exist, making the
+// statement a lightweight transaction.
+func (b *InsertBuilder) IfNotExists() *InsertBuilder {
+	b.ifNotExists = true
+	return b
+}
+
+// TTL expires the inserted values after ttl, rounded down to the second.
+func (b *InsertBuilder) TTL(ttl time.Duration) *InsertBuilder {
+	b.using.ttl, b.using.hasTTL = ttl, true
+	return b
+}
+
+// Timestamp sets the write time of the inserted values.
+func (b *InsertBuilder) Timestamp(t time.Time) *InsertBuilder {
+	b.using.timestamp, b.using.hasTimestamp = t, true
+	return b
+}
+
+func (b *InsertBuilder) Build() (*pb.Query, error) {
+	return b.build(false)
+}
+
+func (b *InsertBuilder) BuildNamed() (*pb.Query, error) {
+	return b.build(true)
+}
+
+func (b *InsertBuilder) build(named bool) (*pb.Query, error) {
+	if b.table == "" {
+		return nil, errors.New("insert requires a table")
+	}
+	if len(b.columns) == 0 {
+		return nil, errors.New("insert requires at least one value")
+	}
+
+	s := newStatement(named)
+	s.write("INSERT INTO ", tableName(b.keyspace, b.table), " (")
+	for i, column := range b.columns {
+		if i > 0 {
+			s.write(", ")
+		}
+		s.write(QuoteIdentifier(column))
+	}
+	s.write(") VALUES (")
+	for i, column := range b.columns {
+		if i > 0 {
+			s.write(", ")
+		}
+		s.bind(column, b.values[i])
+	}
+	s.write(")")
+	if b.ifNotExists {
+		s.write(" IF NOT EXISTS")
+	}
+	b.using.write(s)
+
+	return s.query()
+}
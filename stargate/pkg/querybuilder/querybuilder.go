@@ -0,0 +1,295 @@
+// Package querybuilder builds CQL statements with correctly quoted identifiers
+// and bound values, producing a *pb.Query ready for ExecuteQuery.
+package querybuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// Builder is implemented by every statement builder.
+type Builder interface {
+	// Build returns the statement with positional `?` bind markers.
+	Build() (*pb.Query, error)
+	// BuildNamed returns the statement with named bind markers, derived from
+	// the column names, and the names set on the query's values.
+	BuildNamed() (*pb.Query, error)
+}
+
+// Raw is a CQL fragment, such as `now()` or a literal, written verbatim where a
+// value would otherwise be bound. It must never contain untrusted input.
+type Raw string
+
+var unquotedIdentifier = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedKeywords cannot be used as identifiers unless quoted.
+var reservedKeywords = map[string]bool{
+	"add": true, "allow": true, "alter": true, "and": true, "apply": true, "asc": true, "authorize": true,
+	"batch": true, "begin": true, "by": true, "columnfamily": true, "create": true, "delete": true, "desc": true,
+	"describe": true, "drop": true, "entries": true, "execute": true, "from": true, "full": true, "grant": true,
+	"if": true, "in": true, "index": true, "infinity": true, "insert": true, "into": true, "is": true,
+	"keyspace": true, "limit": true, "materialized": true, "modify": true, "nan": true, "norecursive": true,
+	"not": true, "null": true, "of": true, "on": true, "or": true, "order": true, "primary": true,
+	"rename": true, "replace": true, "revoke": true, "schema": true, "select": true, "set": true, "table": true,
+	"to": true, "token": true, "truncate": true, "unlogged": true, "unset": true, "update": true, "use": true,
+	"using": true, "view": true, "where": true, "with": true,
+}
+
+// QuoteIdentifier returns name as a CQL identifier, quoting it if it contains
+// upper case or special characters or is a reserved keyword.
+func QuoteIdentifier(name string) string {
+	if unquotedIdentifier.MatchString(name) && !reservedKeywords[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func tableName(keyspace, table string) string {
+	if keyspace == "" {
+		return QuoteIdentifier(table)
+	}
+	return QuoteIdentifier(keyspace) + "." + QuoteIdentifier(table)
+}
+
+// Relation is a condition on a column used in WHERE and IF clauses.
+type Relation struct {
+	lhs    string
+	name   string
+	op     string
+	values []interface{}
+	in     bool
+}
+
+func columnRelation(column, op string, value interface{}) Relation {
+	return Relation{lhs: QuoteIdentifier(column), name: column, op: op, values: []interface{}{value}}
+}
+
+// Eq returns the relation `column = value`.
+func Eq(column string, value interface{}) Relation {
+	return columnRelation(column, "=", value)
+}
+
+// Ne returns the relation `column != value`, which is only valid in IF
+// clauses.
+func Ne(column string, value interface{}) Relation {
+	return columnRelation(column, "!=", value)
+}
+
+// Lt returns the relation `column < value`.
+func Lt(column string, value interface{}) Relation {
+	return columnRelation(column, "<", value)
+}
+
+// Lte returns the relation `column <= value`.
+func Lte(column string, value interface{}) Relation {
+	return columnRelation(column, "<=", value)
+}
+
+// Gt returns the relation `column > value`.
+func Gt(column string, value interface{}) Relation {
+	return columnRelation(column, ">", value)
+}
+
+// Gte returns the relation `column >= value`.
+func Gte(column string, value interface{}) Relation {
+	return columnRelation(column, ">=", value)
+}
+
+// In returns the relation `column IN (values...)`, binding each value
+// separately.
+func In(column string, values ...interface{}) Relation {
+	return Relation{lhs: QuoteIdentifier(column), name: column, op: "IN", values: values, in: true}
+}
+
+// Contains returns the relation `column CONTAINS value`.
+func Contains(column string, value interface{}) Relation {
+	return columnRelation(column, "CONTAINS", value)
+}
+
+// ContainsKey returns the relation `column CONTAINS KEY value`.
+func ContainsKey(column string, value interface{}) Relation {
+	return columnRelation(column, "CONTAINS KEY", value)
+}
+
+// TokenRelation builds relations on the token of a partition key, used to scan
+// a table by token range.
+type TokenRelation struct {
+	lhs string
+}
+
+// Token returns a TokenRelation for the partition key columns.
+func Token(columns ...string) TokenRelation {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = QuoteIdentifier(column)
+	}
+	return TokenRelation{lhs: "token(" + strings.Join(quoted, ", ") + ")"}
+}
+
+func (t TokenRelation) relation(op string, value interface{}) Relation {
+	return Relation{lhs: t.lhs, name: "token", op: op, values: []interface{}{value}}
+}
+
+// Eq returns the relation `token(...) = value`.
+func (t TokenRelation) Eq(value interface{}) Relation {
+	return t.relation("=", value)
+}
+
+// Lt returns the relation `token(...) < value`.
+func (t TokenRelation) Lt(value interface{}) Relation {
+	return t.relation("<", value)
+}
+
+// Lte returns the relation `token(...) <= value`.
+func (t TokenRelation) Lte(value interface{}) Relation {
+	return t.relation("<=", value)
+}
+
+// Gt returns the relation `token(...) > value`.
+func (t TokenRelation) Gt(value interface{}) Relation {
+	return t.relation(">", value)
+}
+
+// Gte returns the relation `token(...) >= value`.
+func (t TokenRelation) Gte(value interface{}) Relation {
+	return t.relation(">=", value)
+}
+
+// statement accumulates the CQL and bound values of a statement being built.
+type statement struct {
+	cql    strings.Builder
+	values []*pb.Value
+	names  []string
+	named  bool
+	used   map[string]int
+	err    error
+}
+
+func newStatement(named bool) *statement {
+	return &statement{named: named, used: map[string]int{}}
+}
+
+func (s *statement) write(parts ...string) {
+	for _, part := range parts {
+		s.cql.WriteString(part)
+	}
+}
+
+// bind writes a bind marker for value, or value itself if it is Raw. Named
+// markers are made unique by suffixing repeated names with a counter.
+func (s *statement) bind(name string, value interface{}) {
+	if raw, ok := value.(Raw); ok {
+		s.write(string(raw))
+		return
+	}
+
+	v, err := client.FromGo(value, nil)
+	if err != nil {
+		if s.err == nil {
+			s.err = fmt.Errorf("cannot bind %s: %w", name, err)
+		}
+		return
+	}
+	s.values = append(s.values, v)
+
+	if !s.named {
+		s.write("?")
+		return
+	}
+	s.used[name]++
+	if n := s.used[name]; n > 1 {
+		name += "_" + strconv.Itoa(n)
+	}
+	s.names = append(s.names, name)
+	s.write(":", QuoteIdentifier(name))
+}
+
+func (s *statement) relations(keyword string, relations []Relation) {
+	for i, r := range relations {
+		if i == 0 {
+			s.write(" ", keyword, " ")
+		} else {
+			s.write(" AND ")
+		}
+
+		s.write(r.lhs, " ", r.op, " ")
+		if !r.in {
+			s.bind(r.name, r.values[0])
+			continue
+		}
+		s.write("(")
+		for j, v := range r.values {
+			if j > 0 {
+				s.write(", ")
+			}
+			s.bind(r.name, v)
+		}
+		s.write(")")
+	}
+}
+
+func (s *statement) query() (*pb.Query, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	query := &pb.Query{Cql: s.cql.String()}
+	if len(s.values) > 0 {
+		query.Values = &pb.Values{Values: s.values, ValueNames: s.names}
+	}
+	return query, nil
+}
+
+// using holds the USING clause of a modification statement.
+type using struct {
+	ttl          time.Duration
+	hasTTL       bool
+	timestamp    time.Time
+	hasTimestamp bool
+}
+
+func (u using) write(s *statement) {
+	switch {
+	case u.hasTTL && u.hasTimestamp:
+		s.write(" USING TTL ", u.ttlSeconds(), " AND TIMESTAMP ", u.micros())
+	case u.hasTTL:
+		s.write(" USING TTL ", u.ttlSeconds())
+	case u.hasTimestamp:
+		s.write(" USING TIMESTAMP ", u.micros())
+	}
+}
+
+func (u using) ttlSeconds() string {
+	return strconv.FormatInt(int64(u.ttl/time.Second), 10)
+}
+
+func (u using) micros() string {
+	return strconv.FormatInt(u.timestamp.UnixNano()/int64(time.Microsecond), 10)
+}
+
+// conditions holds the IF clause of a modification statement.
+type conditions struct {
+	relations []Relation
+	exists    bool
+}
+
+func (c conditions) write(s *statement) {
+	if c.exists {
+		s.write(" IF EXISTS")
+		return
+	}
+	s.relations("IF", c.relations)
+}
+
+var (
+	_ Builder = (*SelectBuilder)(nil)
+	_ Builder = (*InsertBuilder)(nil)
+	_ Builder = (*UpdateBuilder)(nil)
+	_ Builder = (*DeleteBuilder)(nil)
+)
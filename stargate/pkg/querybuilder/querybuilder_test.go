@@ -0,0 +1,148 @@
+package querybuilder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stargate/stargate-grpc-go-client/stargate/pkg/client"
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "name", QuoteIdentifier("name"))
+	assert.Equal(t, "user_id2", QuoteIdentifier("user_id2"))
+	assert.Equal(t, `"UserId"`, QuoteIdentifier("UserId"))
+	assert.Equal(t, `"order"`, QuoteIdentifier("order"))
+	assert.Equal(t, `"first name"`, QuoteIdentifier("first name"))
+	assert.Equal(t, `"a""b"`, QuoteIdentifier(`a"b`))
+	assert.Equal(t, `"x"" FROM t; --"`, QuoteIdentifier(`x" FROM t; --`))
+}
+
+func TestBuild(t *testing.T) {
+	ts := time.Unix(1600000000, 123456000)
+
+	tests := []struct {
+		name    string
+		builder Builder
+		cql     string
+		values  []*pb.Value
+	}{
+		{
+			"select all",
+			Select("ks", "users"),
+			"SELECT * FROM ks.users",
+			nil,
+		},
+		{
+			"select",
+			Select("ks", "Users").Columns("id", "Name").Selectors("writetime(email)").
+				Where(Eq("id", 1), Gte("created", "2021")).
+				OrderBy("created", Desc).PerPartitionLimit(2).Limit(10).AllowFiltering(),
+			`SELECT id, "Name", writetime(email) FROM ks."Users" WHERE id = ? AND created >= ? ORDER BY created DESC PER PARTITION LIMIT 2 LIMIT 10 ALLOW FILTERING`,
+			[]*pb.Value{client.FromInt(1), client.FromString("2021")},
+		},
+		{
+			"select distinct in",
+			Select("", "users").Distinct().Columns("id").Where(In("id", 1, 2), Contains("tags", "a"), ContainsKey("attrs", "b")),
+			"SELECT DISTINCT id FROM users WHERE id IN (?, ?) AND tags CONTAINS ? AND attrs CONTAINS KEY ?",
+			[]*pb.Value{client.FromInt(1), client.FromInt(2), client.FromString("a"), client.FromString("b")},
+		},
+		{
+			"select token range",
+			Select("ks", "users").Where(Token("id", "region").Gt(int64(-10)), Token("id", "region").Lte(int64(10))),
+			"SELECT * FROM ks.users WHERE token(id, region) > ? AND token(id, region) <= ?",
+			[]*pb.Value{client.FromInt(-10), client.FromInt(10)},
+		},
+		{
+			"insert",
+			Insert("ks", "users").Value("id", 1).Value("name", "alice").IfNotExists().TTL(90 * time.Second).Timestamp(ts),
+			"INSERT INTO ks.users (id, name) VALUES (?, ?) IF NOT EXISTS USING TTL 90 AND TIMESTAMP 1600000000123456",
+			[]*pb.Value{client.FromInt(1), client.FromString("alice")},
+		},
+		{
+			"insert raw",
+			Insert("ks", "users").Value("id", 1).Value("updated", Raw("toTimestamp(now())")),
+			"INSERT INTO ks.users (id, updated) VALUES (?, toTimestamp(now()))",
+			[]*pb.Value{client.FromInt(1)},
+		},
+		{
+			"update",
+			Update("ks", "users").TTL(time.Minute).
+				Set("name", "bob").SetAt("attrs", "k", "v").Add("tags", []string{"x"}).
+				Remove("scores", []int{1}).Prepend("history", []string{"y"}).
+				Where(Eq("id", 1)).If(Ne("name", "alice")),
+			"UPDATE ks.users USING TTL 60 SET name = ?, attrs[?] = ?, tags = tags + ?, scores = scores - ?, history = ? + history WHERE id = ? IF name != ?",
+			[]*pb.Value{
+				client.FromString("bob"), client.FromString("k"), client.FromString("v"),
+				client.FromCollection(client.FromString("x")), client.FromCollection(client.FromInt(1)),
+				client.FromCollection(client.FromString("y")), client.FromInt(1), client.FromString("alice"),
+			},
+		},
+		{
+			"update if exists",
+			Update("ks", "counters").Add("count", 1).Where(Eq("id", 1)).IfExists(),
+			"UPDATE ks.counters SET count = count + ? WHERE id = ? IF EXISTS",
+			[]*pb.Value{client.FromInt(1), client.FromInt(1)},
+		},
+		{
+			"delete",
+			Delete("ks", "users").Columns("name", "email").Timestamp(ts).Where(Eq("id", 1), Lt("created", 5)),
+			"DELETE name, email FROM ks.users USING TIMESTAMP 1600000000123456 WHERE id = ? AND created < ?",
+			[]*pb.Value{client.FromInt(1), client.FromInt(5)},
+		},
+		{
+			"delete if",
+			Delete("ks", "users").Where(Eq("id", 1)).If(Eq("version", 3), Gt("score", 1.5)),
+			"DELETE FROM ks.users WHERE id = ? IF version = ? AND score > ?",
+			[]*pb.Value{client.FromInt(1), client.FromInt(3), client.FromDouble(1.5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := tt.builder.Build()
+			require.NoError(t, err)
+			assert.Equal(t, tt.cql, query.Cql)
+			assert.Equal(t, tt.values, query.GetValues().GetValues())
+			assert.Empty(t, query.GetValues().GetValueNames())
+		})
+	}
+}
+
+func TestBuildNamed(t *testing.T) {
+	query, err := Select("ks", "users").
+		Where(Eq("Id", 1), Token("id").Gt(int64(0)), Token("id").Lte(int64(100)), In("region", "eu", "us")).
+		BuildNamed()
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`SELECT * FROM ks.users WHERE "Id" = :"Id" AND token(id) > :"token" AND token(id) <= :token_2 AND region IN (:region, :region_2)`,
+		query.Cql)
+	assert.Equal(t, []string{"Id", "token", "token_2", "region", "region_2"}, query.Values.ValueNames)
+	assert.Len(t, query.Values.Values, 5)
+}
+
+func TestBuild_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder Builder
+		err     string
+	}{
+		{"select without table", Select("ks", ""), "select requires a table"},
+		{"insert without values", Insert("ks", "users"), "insert requires at least one value"},
+		{"update without assignments", Update("ks", "users").Where(Eq("id", 1)), "update requires at least one assignment"},
+		{"update without where", Update("ks", "users").Set("name", "bob"), "update requires a where clause"},
+		{"delete without where", Delete("ks", "users"), "delete requires a where clause"},
+		{"unsupported value", Select("ks", "users").Where(Eq("id", make(chan int))), "cannot bind id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package querybuilder
+
+import (
+	"errors"
+	"strconv"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+// Order is the direction of an ORDER BY clause.
+type Order int
+
+const (
+	Asc Order = iota
+	Desc
+)
+
+// SelectBuilder builds a SELECT statement.
+type SelectBuilder struct {
+	keyspace          string
+	table             string
+	distinct          bool
+	selectors         []string
+	where             []Relation
+	orderBy           []string
+	limit             int
+	perPartitionLimit int
+	allowFiltering    bool
+}
+
+// Select starts a SELECT statement on keyspace.table. If keyspace is empty the
+// table is not qualified and the session's keyspace is used.
+func Select(keyspace, table string) *SelectBuilder {
+	return &SelectBuilder{keyspace: keyspace, table: table}
+}
+
+// Columns adds columns to the selection. All columns are selected if none are
+// given.
+func (b *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	for _, column := range columns {
+		b.selectors = append(b.selectors, QuoteIdentifier(column))
+	}
+	return b
+}
+
+// Selectors adds selectors such as `writetime(value)` or `count(*)` to the
+// selection. They are written verbatim and must never contain untrusted
+// input.
+func (b *SelectBuilder) Selectors(selectors ...string) *SelectBuilder {
+	b.selectors = append(b.selectors, selectors...)
+	return b
+}
+
+// Distinct selects only distinct partition keys.
+func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b.distinct = true
+	return b
+}
+
+// Where adds relations to the WHERE clause, joined with AND.
+func (b *SelectBuilder) Where(relations ...Relation) *SelectBuilder {
+	b.where = append(b.where, relations...)
+	return b
+}
+
+// OrderBy adds a clustering column to the ORDER BY clause.
+func (b *SelectBuilder) OrderBy(column string, order Order) *SelectBuilder {
+	direction := " ASC"
+	if order == Desc {
+		direction = " DESC"
+	}
+	b.orderBy = append(b.orderBy, QuoteIdentifier(column)+direction)
+	return b
+}
+
+// Limit limits the number of rows returned.
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+// PerPartitionLimit limits the number of rows returned from each partition.
+func (b *SelectBuilder) PerPartitionLimit(limit int) *SelectBuilder {
+	b.perPartitionLimit = limit
+	return b
+}
+
+// AllowFiltering allows queries that require server side filtering.
+func (b *SelectBuilder) AllowFiltering() *SelectBuilder {
+	b.allowFiltering = true
+	return b
+}
+
+func (b *SelectBuilder) Build() (*pb.Query, error) {
+	return b.build(false)
+}
+
+func (b *SelectBuilder) BuildNamed() (*pb.Query, error) {
+	return b.build(true)
+}
+
+func (b *SelectBuilder) build(named bool) (*pb.Query, error) {
+	if b.table == "" {
+		return nil, errors.New("select requires a table")
+	}
+
+	s := newStatement(named)
+	s.write("SELECT ")
+	if b.distinct {
+		s.write("DISTINCT ")
+	}
+	if len(b.selectors) == 0 {
+		s.write("*")
+	}
+	for i, selector := range b.selectors {
+		if i > 0 {
+			s.write(", ")
+		}
+		s.write(selector)
+	}
+	s.write(" FROM ", tableName(b.keyspace, b.table))
+	s.relations("WHERE", b.where)
+	for i, order := range b.orderBy {
+		if i == 0 {
+			s.write(" ORDER BY ")
+		} else {
+			s.write(", ")
+		}
+		s.write(order)
+	}
+	if b.perPartitionLimit > 0 {
+		s.write(" PER PARTITION LIMIT ", strconv.Itoa(b.perPartitionLimit))
+	}
+	if b.limit > 0 {
+		s.write(" LIMIT ", strconv.Itoa(b.limit))
+	}
+	if b.allowFiltering {
+		s.write(" ALLOW FILTERING")
+	}
+
+	return s.query()
+}
@@ -0,0 +1,149 @@
+package querybuilder
+
+import (
+	"errors"
+	"time"
+
+	pb "github.com/stargate/stargate-grpc-go-client/stargate/pkg/proto"
+)
+
+type assignment struct {
+	column string
+	key    interface{}
+	hasKey bool
+	value  interface{}
+	op     string
+}
+
+// UpdateBuilder builds an UPDATE statement.
+type UpdateBuilder struct {
+	keyspace    string
+	table       string
+	assignments []assignment
+	where       []Relation
+	conditions  conditions
+	using       using
+}
+
+// Update starts an UPDATE statement on keyspace.table.
+func Update(keyspace, table string) *UpdateBuilder {
+	return &UpdateBuilder{keyspace: keyspace, table: table}
+}
+
+// Set adds the assignment `column = value`.
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	b.assignments = append(b.assignments, assignment{column: column, value: value, op: "="})
+	return b
+}
+
+// SetAt adds the assignment `column[key] = value`, setting an element of a map
+// or list.
+func (b *UpdateBuilder) SetAt(column string, key, value interface{}) *UpdateBuilder {
+	b.assignments = append(b.assignments, assignment{column: column, key: key, hasKey: true, value: value, op: "="})
+	return b
+}
+
+// Add adds the assignment `column = column + value`, incrementing a counter or
+// appending to a collection.
+func (b *UpdateBuilder) Add(column string, value interface{}) *UpdateBuilder {
+	b.assignments = append(b.assignments, assignment{column: column, value: value, op: "+"})
+	return b
+}
+
+// Remove adds the assignment `column = column - value`, decrementing a counter
+// or removing from a collection.
+func (b *UpdateBuilder) Remove(column string, value interface{}) *UpdateBuilder {
+	b.assignments = append(b.assignments, assignment{column: column, value: value, op: "-"})
+	return b
+}
+
+// Prepend adds the assignment `column = value + column`, prepending to a list.
+func (b *UpdateBuilder) Prepend(column string, value interface{}) *UpdateBuilder {
+	b.assignments = append(b.assignments, assignment{column: column, value: value, op: "prepend"})
+	return b
+}
+
+// Where adds relations to the WHERE clause, joined with AND.
+func (b *UpdateBuilder) Where(relations ...Relation) *UpdateBuilder {
+	b.where = append(b.where, relations...)
+	return b
+}
+
+// If adds conditions to the IF clause, making the statement a lightweight
+// transaction.
+func (b *UpdateBuilder) If(relations ...Relation) *UpdateBuilder {
+	b.conditions.relations = append(b.conditions.relations, relations...)
+	return b
+}
+
+// IfExists only updates the row if it already exists.
+func (b *UpdateBuilder) IfExists() *UpdateBuilder {
+	b.conditions.exists = true
+	return b
+}
+
+// TTL expires the updated values after ttl, rounded down to the second.
+func (b *UpdateBuilder) TTL(ttl time.Duration) *UpdateBuilder {
+	b.using.ttl, b.using.hasTTL = ttl, true
+	return b
+}
+
+// Timestamp sets the write time of the updated values.
+func (b *UpdateBuilder) Timestamp(t time.Time) *UpdateBuilder {
+	b.using.timestamp, b.using.hasTimestamp = t, true
+	return b
+}
+
+func (b *UpdateBuilder) Build() (*pb.Query, error) {
+	return b.build(false)
+}
+
+func (b *UpdateBuilder) BuildNamed() (*pb.Query, error) {
+	return b.build(true)
+}
+
+func (b *UpdateBuilder) build(named bool) (*pb.Query, error) {
+	if b.table == "" {
+		return nil, errors.New("update requires a table")
+	}
+	if len(b.assignments) == 0 {
+		return nil, errors.New("update requires at least one assignment")
+	}
+	if len(b.where) == 0 {
+		return nil, errors.New("update requires a where clause")
+	}
+
+	s := newStatement(named)
+	s.write("UPDATE ", tableName(b.keyspace, b.table))
+	b.using.write(s)
+	s.write(" SET ")
+	for i, a := range b.assignments {
+		if i > 0 {
+			s.write(", ")
+		}
+
+		column := QuoteIdentifier(a.column)
+		s.write(column)
+		switch a.op {
+		case "=":
+			if a.hasKey {
+				s.write("[")
+				s.bind(a.column+"_key", a.key)
+				s.write("]")
+			}
+			s.write(" = ")
+			s.bind(a.column, a.value)
+		case "prepend":
+			s.write(" = ")
+			s.bind(a.column, a.value)
+			s.write(" + ", column)
+		default:
+			s.write(" = ", column, " ", a.op, " ")
+			s.bind(a.column, a.value)
+		}
+	}
+	s.relations("WHERE", b.where)
+	b.conditions.write(s)
+
+	return s.query()
+}